@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"ethstats/client/app/model"
+	"fmt"
+	"github.com/ethereum/go-ethereum/rpc"
+	"strings"
+	"sync"
+)
+
+const (
+	chainTypeEthereum = "ethereum"
+	chainTypeOptimism = "optimism"
+	chainTypeArbitrum = "arbitrum"
+)
+
+// adapter collects the stats specific to a kind of chain, beside the generic eth_* calls
+type adapter interface {
+	rollup(ctx context.Context, c caller) (*model.Rollup, error)
+}
+
+// newAdapter creates the adapter of the chain type, a plain ethereum chain has no adapter
+func newAdapter(chainType, rollupUrl, l1Url string, metrics *rpcMetrics) (adapter, error) {
+	switch chainType {
+	case "", chainTypeEthereum:
+		return nil, nil
+	case chainTypeOptimism:
+		if rollupUrl == "" {
+			return nil, fmt.Errorf("chain type %s requires the rollup node url", chainType)
+		}
+		return &optimismAdapter{rollupNode: &lazyClient{url: rollupUrl, metrics: metrics, prefix: "rollup."}}, nil
+	case chainTypeArbitrum:
+		if l1Url == "" {
+			return nil, fmt.Errorf("chain type %s requires the l1 url", chainType)
+		}
+		return &arbitrumAdapter{l1: &lazyClient{url: l1Url, metrics: metrics, prefix: "l1."}}, nil
+	}
+	return nil, fmt.Errorf("unsupported chain type %s", chainType)
+}
+
+// lazyClient dials the rpc url on first use and keeps the connection until a call fails
+// without an rpc error response, the calls are timed under the prefix
+type lazyClient struct {
+	url     string
+	metrics *rpcMetrics
+	prefix  string
+	lock    sync.Mutex
+	client  *rpc.Client
+}
+
+func (l *lazyClient) get(ctx context.Context) (caller, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.client == nil {
+		client, err := rpc.DialContext(ctx, l.url)
+		if err != nil {
+			return nil, err
+		}
+		l.client = client
+	}
+	return &lazyCaller{caller: l.metrics.wrap(l.client, l.prefix), lazy: l, client: l.client}, nil
+}
+
+// drop closes the client if it is still the current one, the next call dials again
+func (l *lazyClient) drop(client *rpc.Client) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.client == client {
+		l.client.Close()
+		l.client = nil
+	}
+}
+
+// lazyCaller drops the connection of its lazyClient once a call fails on the transport, an
+// error answered by the endpoint keeps it
+type lazyCaller struct {
+	caller
+	lazy   *lazyClient
+	client *rpc.Client
+}
+
+func (c *lazyCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	err := c.caller.CallContext(ctx, result, method, args...)
+	c.check(err)
+	return err
+}
+
+func (c *lazyCaller) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	err := c.caller.BatchCallContext(ctx, b)
+	c.check(err)
+	return err
+}
+
+func (c *lazyCaller) check(err error) {
+	var rpcErr rpc.Error
+	if err != nil && !errors.As(err, &rpcErr) {
+		c.lazy.drop(c.client)
+	}
+}
+
+// rpcReverted reports whether the call failed because the execution reverted, which is an
+// answer of the node rather than a failure
+func rpcReverted(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	// geth answers the reverts with code 3, older nodes with -32000 and the message
+	return rpcErr.ErrorCode() == 3 || strings.HasPrefix(rpcErr.Error(), "execution reverted")
+}
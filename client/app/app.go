@@ -9,7 +9,6 @@ import (
 	"ethstats/client/config"
 	"ethstats/common/util/connutil"
 	"fmt"
-	"github.com/bitxx/evm-utils"
 	"github.com/bitxx/logger"
 	"github.com/bitxx/logger/logbase"
 	"os"
@@ -22,27 +21,39 @@ import (
 )
 
 type App struct {
-	node    model.Node
-	readyCh chan struct{}
-	pongCh  chan struct{}
-	logger  *logbase.Helper
+	node      model.Node
+	mismatch  []string
+	audit     *model.Audit
+	adapter   adapter
+	metrics   *rpcMetrics
+	endpoints *endpoints
+	readyCh   chan struct{}
+	pongCh    chan *clockSample
+	clock     clockEstimator
+	latency   latencyTracker
+	logger    *logbase.Helper
 }
 
-func NewApp() *App {
-	node := model.Node{
+// newNode returns the identity known before discovering the node, from the config
+func newNode() model.Node {
+	return model.Node{
 		Id:         config.ApplicationConfig.Name,
 		Name:       config.ApplicationConfig.Name,
 		Contact:    config.ApplicationConfig.Contract,
 		ChainPort:  config.ChainConfig.Port,
 		OSPlatform: runtime.GOARCH,
 		OS:         runtime.GOOS,
-		Client:     config.ApplicationConfig.Version,
+		Agent:      config.ApplicationConfig.Version,
+		Labels:     config.ApplicationConfig.Labels,
 	}
+}
 
-	return &App{
-		node:    node,
+func NewApp() *App {
+	a := &App{
+		node:    newNode(),
 		readyCh: make(chan struct{}),
-		pongCh:  make(chan struct{}),
+		pongCh:  make(chan *clockSample),
+		metrics: newRpcMetrics(),
 		logger: logger.NewLogger(
 			logger.WithType(config.LoggerConfig.Type),
 			logger.WithPath(config.LoggerConfig.Path),
@@ -51,6 +62,15 @@ func NewApp() *App {
 			logger.WithCap(config.LoggerConfig.Cap),
 		),
 	}
+
+	a.endpoints = newEndpoints(config.ChainConfig, a.metrics)
+
+	var err error
+	a.adapter, err = newAdapter(config.ChainConfig.Type, config.ChainConfig.RollupUrl, config.ChainConfig.L1Url, a.metrics)
+	if err != nil {
+		a.logger.Fatal("chain adapter init error: ", err)
+	}
+	return a
 }
 
 func (a *App) Start() {
@@ -75,6 +95,9 @@ func (a *App) Start() {
 		}
 	}()
 
+	// the node may be restarted or replaced while disconnected, discover it again
+	a.node = newNode()
+
 	conn, err = connutil.NewDialConn(config.ApplicationConfig.ServerUrl)
 	if err != nil {
 		a.logger.Warn("dial error: ", err)
@@ -137,6 +160,7 @@ func (a *App) readLoop(conn *connutil.ConnWrapper) {
 			a.logger.Warn("received and decode message error: ", err)
 			return
 		}
+		arrivedAt := time.Now()
 		// Not a system ping, try to decode an actual state message
 		var msg map[string][]interface{}
 		if err := json.Unmarshal(blob, &msg); err != nil {
@@ -168,7 +192,7 @@ func (a *App) readLoop(conn *connutil.ConnWrapper) {
 			}
 			return
 		case "node-pong":
-			a.pongCh <- struct{}{}
+			a.pongCh <- parsePong(blob, arrivedAt)
 		}
 
 	}
@@ -179,7 +203,7 @@ func (a *App) reportLatency(conn *connutil.ConnWrapper) error {
 
 	// if is local node,detect the process
 	nodeStatus := "running" //nod
-	if strings.Contains(config.ChainConfig.Url, "127.0.0.1") {
+	if strings.Contains(a.endpoints.url(), "127.0.0.1") {
 		_, err1 := RunCmd("ps axu |grep 'geth -' |grep -v grep") // 'geth -',use for query easy
 		_, err2 := RunCmd("ps axu |grep beacon-chain |grep -v grep")
 		_, err3 := RunCmd("ps axu |grep validator |grep -v grep")
@@ -189,9 +213,10 @@ func (a *App) reportLatency(conn *connutil.ConnWrapper) error {
 	}
 
 	ping := map[string][]interface{}{
-		"emit": {"node-ping", map[string]string{
+		"emit": {"node-ping", map[string]interface{}{
 			"id":         config.ApplicationConfig.Name,
 			"clientTime": start.String(),
+			"sentAt":     start.UnixMilli(),
 			"nodeStatus": nodeStatus,
 		}},
 	}
@@ -199,79 +224,170 @@ func (a *App) reportLatency(conn *connutil.ConnWrapper) error {
 	if err := conn.WriteJSON(ping); err != nil {
 		return err
 	}
-	// Wait for the pong request to arrive back
-	select {
-	case <-a.pongCh:
-		// Pong delivered, report the latency
-	case <-time.After(10 * time.Second):
-		// MsgPing timeout, abort
-		return errors.New("ping timed out")
+	// Wait for the pong request to arrive back, a late pong of a timed out ping is skipped
+	timeout := time.After(10 * time.Second)
+	for pong := false; !pong; {
+		select {
+		case sample := <-a.pongCh:
+			// Pong delivered, report the latency
+			if sample != nil {
+				if sample.SentAt != start.UnixMilli() {
+					continue
+				}
+				a.clock.add(*sample)
+			}
+			pong = true
+		case <-timeout:
+			// MsgPing timeout, abort
+			a.latency.addTimeout()
+			return errors.New("ping timed out")
+		}
 	}
-	latency := strconv.Itoa(int((time.Since(start) / time.Duration(1)).Nanoseconds() / 1000000))
+	rtt := time.Since(start).Milliseconds()
+	a.latency.add(rtt)
+	latency := strconv.FormatInt(rtt, 10)
 
 	// Send back the measured latency
 	a.logger.Trace("sending measured latency: ", latency)
 
 	stats := map[string][]interface{}{
-		"emit": {"latency", map[string]string{
+		"emit": {"latency", map[string]interface{}{
 			"id":      config.ApplicationConfig.Name,
 			"latency": latency,
+			"quality": a.latency.quality(),
 		}},
 	}
 	return conn.WriteJSON(stats)
 }
 
 func (a *App) reportStats(conn *connutil.ConnWrapper) error {
-	ethClient := evmutils.NewEthClient(config.ChainConfig.Url, config.ChainConfig.Timeout)
-	chain, err := ethClient.Chain()
+	// every stage of the report has its own chain timeout, so a hung stage doesn't starve the
+	// next ones
+	stage := func(fetch func(ctx context.Context)) {
+		ctx, cancel := a.endpoints.withTimeout(context.Background())
+		defer cancel()
+		fetch(ctx)
+	}
+
+	var (
+		c       caller
+		changed bool
+		err     error
+	)
+	stage(func(ctx context.Context) {
+		c, changed, err = a.endpoints.get(ctx)
+	})
 	if err != nil {
 		return err
 	}
-	c := chain.RemoteRpcClient
-	// peer count
-	peerCount, _ := c.PeerCount(context.Background())
-
-	// is active
-	active := false
-	if peerCount > 0 {
-		active = true
+	if changed {
+		// another endpoint may be another node
+		a.logger.Info("using rpc endpoint ", a.endpoints.status().Url)
+		a.node = newNode()
 	}
 
-	// gas price
-	gasPrice, _ := c.SuggestGasPrice(context.Background())
-
-	// is syncing
-	process, err := c.SyncProgress(context.Background())
-	syncing := false
-	if err == nil && process != nil {
-		progress := process.CurrentBlock - process.StartingBlock
-		total := process.HighestBlock - process.StartingBlock
-		if progress/total < 1 {
-			syncing = true
+	// peer count, gas price, sync progress, latest/safe/finalized block and pending count
+	var snap *snapshot
+	var errs map[string]string
+	stage(func(ctx context.Context) {
+		snap, errs = fetchSnapshot(ctx, c)
+	})
+
+	// node identity, discovered once per connection
+	if a.node.ChainId == "" {
+		stage(func(ctx context.Context) {
+			if err := fetchNodeIdentity(ctx, c, &a.node); err != nil {
+				errs["NodeInfo"] = err.Error()
+			}
+		})
+		a.mismatch = verifyNetwork(&a.node, config.ChainConfig.ChainId, config.ChainConfig.NetworkId, config.ChainConfig.Genesis)
+		if len(a.mismatch) > 0 {
+			a.logger.Error("node is on the wrong network: ", strings.Join(a.mismatch, "; "))
+		}
+	}
+
+	// rollup health of L2 chains
+	var rollup *model.Rollup
+	if a.adapter != nil {
+		stage(func(ctx context.Context) {
+			if rollup, err = a.adapter.rollup(ctx, c); err != nil {
+				errs["Rollup"] = err.Error()
+			}
+		})
+	}
+
+	// rpc exposure audit, the result is reported until the next audit
+	interval := config.AuditConfig.Interval
+	if interval > 0 && (a.audit == nil || time.Now().Unix()-a.audit.Time >= interval) {
+		stage(func(ctx context.Context) {
+			a.audit = runAudit(ctx, c, a.endpoints.url(), config.AuditConfig.RpcPort, config.AuditConfig.EnginePort)
+		})
+		for _, finding := range a.audit.Findings {
+			a.logger.Warnf("audit %s finding [%s]: %s", finding.Severity, finding.Check, finding.Message)
 		}
 	}
 
-	// latest block
-	latestBlock, err := c.BlockByNumber(context.Background(), nil)
-	block := model.Block{}
-	if err == nil {
-		block.Number = latestBlock.NumberU64()
-		block.Hash = latestBlock.Hash().String()
-		block.Difficulty = latestBlock.Difficulty().Uint64()
-		block.Time = latestBlock.Time()
-		//block.Transactions = latestBlock.Transactions()
-		//block.Uncles = latestBlock.Uncles()
+	status := model.StatusOK
+	if len(a.mismatch) > 0 {
+		status = model.StatusWrongNetwork
 	}
-	pendingCount, _ := c.PendingTransactionCount(context.Background())
 
+	// fee market
+	var fee *model.Fee
+	stage(func(ctx context.Context) {
+		if fee, err = fetchFee(ctx, c, config.ChainConfig.FeeBlocks, config.ChainConfig.FeePercentiles); err != nil {
+			errs["Fee"] = err.Error()
+		}
+	})
+
+	// peer list
+	var peers *model.Peers
+	if config.ChainConfig.Peers {
+		stage(func(ctx context.Context) {
+			if peers, err = fetchPeers(ctx, c, a.node.NodeId); err != nil {
+				errs["Peers"] = err.Error()
+			}
+		})
+	}
+
+	// mempool, the txpool namespace may not be exposed
+	var txpool *model.Txpool
+	stage(func(ctx context.Context) {
+		if txpool, err = fetchTxpool(ctx, c, config.ChainConfig.TxpoolInspect, config.ChainConfig.TxpoolSenders); err != nil {
+			errs["Txpool"] = err.Error()
+		}
+	})
+
+	for field, msg := range errs {
+		// the finality tags and the txpool namespace are not served by every node
+		if field == "Finality" || field == "Txpool" {
+			a.logger.Debugf("%s request failed: %s", field, msg)
+			continue
+		}
+		a.logger.Warnf("%s request failed: %s", field, msg)
+	}
 	stats := model.Stats{
+		Status:    status,
+		Mismatch:  a.mismatch,
 		NodeInfo:  a.node,
-		Active:    active,
-		PeerCount: peerCount,
-		Pending:   pendingCount,
-		GasPrice:  gasPrice.Int64(),
-		Syncing:   syncing,
-		Block:     &block,
+		Active:    snap.PeerCount > 0,
+		PeerCount: snap.PeerCount,
+		Peers:     peers,
+		Pending:   snap.Pending,
+		Txpool:    txpool,
+		GasPrice:  snap.GasPrice,
+		Fee:       fee,
+		Syncing:   snap.Sync != nil,
+		Sync:      snap.Sync,
+		ChainId:   a.node.ChainId,
+		Block:     snap.Block,
+		Finality:  snap.Finality,
+		Security:  a.audit,
+		Rollup:    rollup,
+		Rpc:       a.metrics.report(),
+		Endpoint:  a.endpoints.status(),
+		Errors:    errs,
+		Clock:     a.clock.estimate(),
 	}
 	report := map[string][]interface{}{
 		"emit": {"stats", stats},
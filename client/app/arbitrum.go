@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// arbNodeInterface is the virtual contract of Nitro nodes answering the batch queries
+var arbNodeInterface = common.HexToAddress("0x00000000000000000000000000000000000000c8")
+
+var findBatchSelector = crypto.Keccak256([]byte("findBatchContainingBlock(uint64)"))[:4]
+
+// arbMaxBatchSearch bounds the blocks searched back for the newest batched block
+const arbMaxBatchSearch = 1 << 20
+
+// arbBlock is the part of the Nitro block we need, l1BlockNumber is the L1 block the sequencer saw
+type arbBlock struct {
+	Number        hexutil.Uint64 `json:"number"`
+	Time          hexutil.Uint64 `json:"timestamp"`
+	L1BlockNumber hexutil.Uint64 `json:"l1BlockNumber"`
+}
+
+// arbitrumAdapter reports the sequencer lag against L1, and the batch lag, which is how far
+// the newest block posted to L1 in a batch is behind the latest block
+type arbitrumAdapter struct {
+	l1 *lazyClient
+}
+
+func (a *arbitrumAdapter) rollup(ctx context.Context, c caller) (*model.Rollup, error) {
+	latest, err := arbGetBlock(ctx, c, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	l1, err := a.l1.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var l1Head hexutil.Uint64
+	if err = l1.CallContext(ctx, &l1Head, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+	rollup := &model.Rollup{
+		Type:     chainTypeArbitrum,
+		UnsafeL2: uint64(latest.Number),
+		L1Head:   uint64(l1Head),
+		L1Origin: uint64(latest.L1BlockNumber),
+	}
+	if rollup.L1Head > rollup.L1Origin {
+		rollup.L1Lag = rollup.L1Head - rollup.L1Origin
+	}
+	// nitro maps safe and finalized to the batches confirmed on L1
+	if safe, err := arbGetBlock(ctx, c, rpc.SafeBlockNumber); err == nil {
+		rollup.SafeL2 = uint64(safe.Number)
+	}
+	if finalized, err := arbGetBlock(ctx, c, rpc.FinalizedBlockNumber); err == nil {
+		rollup.FinalizedL2 = uint64(finalized.Number)
+	}
+
+	batched, err := arbNewestBatchedBlock(ctx, c, rollup.UnsafeL2)
+	if err != nil {
+		return rollup, err
+	}
+	rollup.BatchL2 = batched
+	rollup.BatchLag = rollup.UnsafeL2 - batched
+	if rollup.BatchLag > 0 {
+		if block, err := arbGetBlock(ctx, c, rpc.BlockNumber(batched)); err == nil && latest.Time > block.Time {
+			rollup.BatchLagTime = uint64(latest.Time - block.Time)
+		}
+	}
+	return rollup, nil
+}
+
+func arbGetBlock(ctx context.Context, c caller, tag rpc.BlockNumber) (*arbBlock, error) {
+	var block arbBlock
+	if err := c.CallContext(ctx, &block, "eth_getBlockByNumber", tag, false); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// arbInBatch checks whether the block is already posted in a batch, the node interface reverts
+// otherwise. Any other error is returned, so a failing node doesn't look like a batch lag
+func arbInBatch(ctx context.Context, c caller, number uint64) (bool, error) {
+	data := make([]byte, 36)
+	copy(data, findBatchSelector)
+	binary.BigEndian.PutUint64(data[28:], number)
+	var result hexutil.Bytes
+	err := c.CallContext(ctx, &result, "eth_call", map[string]interface{}{
+		"to":   arbNodeInterface,
+		"data": hexutil.Bytes(data),
+	}, rpc.LatestBlockNumber)
+	if rpcReverted(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(result) == 32, nil
+}
+
+// arbNewestBatchedBlock searches the newest batched block, stepping back exponentially from
+// the latest block and then bisecting, so it takes about 2*log2(lag) calls
+func arbNewestBatchedBlock(ctx context.Context, c caller, latest uint64) (uint64, error) {
+	batched, err := arbInBatch(ctx, c, latest)
+	if err != nil {
+		return 0, err
+	}
+	if batched {
+		return latest, nil
+	}
+	// hi is known not batched, lo is known batched
+	hi, lo, step := latest, uint64(0), uint64(64)
+	for {
+		if step > arbMaxBatchSearch || step >= latest {
+			break
+		}
+		if batched, err = arbInBatch(ctx, c, latest-step); err != nil {
+			return 0, err
+		}
+		if batched {
+			lo = latest - step
+			break
+		}
+		hi = latest - step
+		step *= 2
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if batched, err = arbInBatch(ctx, c, mid); err != nil {
+			return 0, err
+		}
+		if batched {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
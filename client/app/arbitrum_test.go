@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"testing"
+)
+
+type testRpcError struct {
+	code int
+	msg  string
+}
+
+func (e *testRpcError) Error() string  { return e.msg }
+func (e *testRpcError) ErrorCode() int { return e.code }
+
+// testBatchCaller answers findBatchContainingBlock of the blocks up to batched and reverts
+// above, or fails every call with err
+type testBatchCaller struct {
+	batched uint64
+	err     error
+}
+
+func (c *testBatchCaller) CallContext(_ context.Context, result interface{}, _ string, args ...interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	data := args[0].(map[string]interface{})["data"].(hexutil.Bytes)
+	if binary.BigEndian.Uint64(data[28:]) > c.batched {
+		return &testRpcError{code: 3, msg: "execution reverted"}
+	}
+	*result.(*hexutil.Bytes) = make([]byte, 32)
+	return nil
+}
+
+func (c *testBatchCaller) BatchCallContext(context.Context, []rpc.BatchElem) error {
+	return errors.New("not supported")
+}
+
+func TestArbNewestBatchedBlock(t *testing.T) {
+	ctx := context.Background()
+	if batched, err := arbNewestBatchedBlock(ctx, &testBatchCaller{batched: 900}, 1000); err != nil || batched != 900 {
+		t.Errorf("unexpected newest batched block %d, error %v", batched, err)
+	}
+	if batched, err := arbNewestBatchedBlock(ctx, &testBatchCaller{batched: 1000}, 1000); err != nil || batched != 1000 {
+		t.Errorf("unexpected newest batched block %d, error %v", batched, err)
+	}
+	// a failing node is not a batch lag
+	c := &testBatchCaller{err: context.DeadlineExceeded}
+	if _, err := arbNewestBatchedBlock(ctx, c, 1000); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error %v", err)
+	}
+	c.err = &testRpcError{code: -32601, msg: "the method eth_call does not exist/is not available"}
+	if _, err := arbNewestBatchedBlock(ctx, c, 1000); err == nil {
+		t.Error("rpc error taken as not batched")
+	}
+}
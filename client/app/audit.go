@@ -0,0 +1,151 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"ethstats/client/app/model"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const (
+	defaultEnginePort = "8551"
+	auditDialTimeout  = 3 * time.Second
+)
+
+// riskyNamespaces are the rpc namespaces that must not be exposed, and their severity
+var riskyNamespaces = map[string]string{
+	"admin":    model.SeverityHigh,
+	"personal": model.SeverityHigh,
+	"debug":    model.SeverityMedium,
+}
+
+// runAudit checks the rpc exposure of the node. The interface and engine checks probe the
+// host of chainUrl, so they are only meaningful if the client runs beside the node
+func runAudit(ctx context.Context, c caller, chainUrl, rpcPort, enginePort string) *model.Audit {
+	audit := &model.Audit{Time: time.Now().Unix(), Findings: []model.AuditFinding{}}
+
+	// enabled namespaces
+	var modules map[string]string
+	risky := false
+	if err := c.CallContext(ctx, &modules, "rpc_modules"); err == nil {
+		names := make([]string, 0, len(modules))
+		for name := range modules {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if severity, ok := riskyNamespaces[name]; ok {
+				risky = true
+				audit.Findings = append(audit.Findings, model.AuditFinding{
+					Check:    "namespace",
+					Severity: severity,
+					Message:  fmt.Sprintf("namespace %s is enabled over rpc", name),
+				})
+			}
+		}
+	}
+
+	// accounts managed by the node
+	var accounts []common.Address
+	if err := c.CallContext(ctx, &accounts, "eth_accounts"); err == nil && len(accounts) > 0 {
+		audit.Findings = append(audit.Findings, model.AuditFinding{
+			Check:    "accounts",
+			Severity: model.SeverityHigh,
+			Message:  fmt.Sprintf("%d accounts are available over rpc, first %s", len(accounts), accounts[0].String()),
+		})
+	}
+
+	host, port := splitChainUrl(chainUrl)
+	if rpcPort == "" {
+		rpcPort = port
+	}
+
+	// rpc port answering on non-loopback interfaces
+	if rpcPort != "" {
+		for _, ip := range exposedAddrs(rpcPort) {
+			severity := model.SeverityMedium
+			if risky {
+				severity = model.SeverityCritical
+			}
+			audit.Findings = append(audit.Findings, model.AuditFinding{
+				Check:    "rpc-exposed",
+				Severity: severity,
+				Message:  fmt.Sprintf("rpc port answers on %s", net.JoinHostPort(ip, rpcPort)),
+			})
+		}
+	}
+
+	// engine api without jwt
+	if enginePort == "" {
+		enginePort = defaultEnginePort
+	}
+	if open, err := engineWithoutJWT(ctx, net.JoinHostPort(host, enginePort)); err == nil && open {
+		audit.Findings = append(audit.Findings, model.AuditFinding{
+			Check:    "engine-jwt",
+			Severity: model.SeverityCritical,
+			Message:  fmt.Sprintf("engine api on port %s accepts requests without jwt", enginePort),
+		})
+	}
+	return audit
+}
+
+// splitChainUrl returns the host and port of the chain url, the host defaults to loopback
+// for ipc paths
+func splitChainUrl(chainUrl string) (host, port string) {
+	host = "127.0.0.1"
+	u, err := url.Parse(chainUrl)
+	if err != nil || u.Host == "" {
+		return host, ""
+	}
+	if h := u.Hostname(); h != "" && h != "localhost" {
+		host = h
+	}
+	return host, u.Port()
+}
+
+// exposedAddrs returns the non-loopback interface addresses on which the port accepts connections
+func exposedAddrs(port string) []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var exposed []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ip := ipNet.IP.String()
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, port), auditDialTimeout)
+		if err != nil {
+			continue
+		}
+		_ = conn.Close()
+		exposed = append(exposed, ip)
+	}
+	return exposed
+}
+
+// engineWithoutJWT sends an unauthenticated engine request, a node enforcing jwt answers 401 or 403
+func engineWithoutJWT(ctx context.Context, addr string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, auditDialTimeout)
+	defer cancel()
+	body := `{"jsonrpc":"2.0","id":1,"method":"engine_exchangeCapabilities","params":[[]]}`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+addr, bytes.NewBufferString(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
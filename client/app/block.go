@@ -0,0 +1,56 @@
+package app
+
+import (
+	"encoding/json"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// rpcBlock is the raw eth_getBlockByNumber result. The header is decoded by hand instead of
+// through types.Header, so the fields added by later forks (blob gas) are not dropped
+type rpcBlock struct {
+	Number        hexutil.Uint64    `json:"number"`
+	Hash          common.Hash       `json:"hash"`
+	ParentHash    common.Hash       `json:"parentHash"`
+	Miner         common.Address    `json:"miner"`
+	Difficulty    *hexutil.Big      `json:"difficulty"`
+	GasUsed       hexutil.Uint64    `json:"gasUsed"`
+	GasLimit      hexutil.Uint64    `json:"gasLimit"`
+	BaseFee       *hexutil.Big      `json:"baseFeePerGas"`
+	Transactions  []common.Hash     `json:"transactions"`
+	Uncles        []common.Hash     `json:"uncles"`
+	Withdrawals   []json.RawMessage `json:"withdrawals"`
+	BlobGasUsed   *hexutil.Uint64   `json:"blobGasUsed"`
+	ExcessBlobGas *hexutil.Uint64   `json:"excessBlobGas"`
+	Size          hexutil.Uint64    `json:"size"`
+	Time          hexutil.Uint64    `json:"timestamp"`
+}
+
+func (b *rpcBlock) toModel() *model.Block {
+	block := &model.Block{
+		Number:          uint64(b.Number),
+		Hash:            b.Hash.String(),
+		ParentHash:      b.ParentHash.String(),
+		Miner:           b.Miner.String(),
+		Difficulty:      (*math.Decimal256)(b.Difficulty),
+		GasUsed:         uint64(b.GasUsed),
+		GasLimit:        uint64(b.GasLimit),
+		BaseFee:         (*math.Decimal256)(b.BaseFee),
+		TxCount:         len(b.Transactions),
+		UncleCount:      len(b.Uncles),
+		WithdrawalCount: len(b.Withdrawals),
+		Size:            uint64(b.Size),
+		Time:            uint64(b.Time),
+	}
+	if b.BlobGasUsed != nil {
+		blobGasUsed := uint64(*b.BlobGasUsed)
+		block.BlobGasUsed = &blobGasUsed
+	}
+	if b.ExcessBlobGas != nil {
+		excessBlobGas := uint64(*b.ExcessBlobGas)
+		block.ExcessBlobGas = &excessBlobGas
+	}
+	return block
+}
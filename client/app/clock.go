@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"ethstats/client/app/model"
+	"time"
+)
+
+// clockSamples is the number of latest ping/pong exchanges the offset is estimated from
+const clockSamples = 8
+
+// clockSample is the four timestamps of a ping/pong exchange, unix milliseconds: the ping sent
+// by the client, received by the server, the pong sent by the server, received by the client
+type clockSample struct {
+	SentAt     int64 `json:"sentAt"`
+	ReceivedAt int64 `json:"receivedAt"`
+	SentBackAt int64 `json:"sentBackAt"`
+	ArrivedAt  int64 `json:"-"`
+}
+
+func (s clockSample) offset() int64 {
+	return ((s.ReceivedAt - s.SentAt) + (s.SentBackAt - s.ArrivedAt)) / 2
+}
+
+func (s clockSample) rtt() int64 {
+	return (s.ArrivedAt - s.SentAt) - (s.SentBackAt - s.ReceivedAt)
+}
+
+// clockEstimator keeps the latest exchanges, the one with the lowest round-trip has the least
+// network asymmetry, so its offset is used like the NTP clock filter does
+type clockEstimator struct {
+	samples []clockSample
+}
+
+func (e *clockEstimator) add(sample clockSample) {
+	e.samples = append(e.samples, sample)
+	if len(e.samples) > clockSamples {
+		e.samples = e.samples[len(e.samples)-clockSamples:]
+	}
+}
+
+// estimate returns the clock offset, nil before the first exchange
+func (e *clockEstimator) estimate() *model.Clock {
+	if len(e.samples) == 0 {
+		return nil
+	}
+	best := e.samples[0]
+	for _, sample := range e.samples[1:] {
+		if sample.rtt() < best.rtt() {
+			best = sample
+		}
+	}
+	return &model.Clock{Offset: best.offset(), Rtt: best.rtt(), Time: time.Now().Unix()}
+}
+
+// parsePong decodes the node-pong timestamps, nil if the server doesn't send them
+func parsePong(blob json.RawMessage, arrivedAt time.Time) *clockSample {
+	var msg struct {
+		Emit []json.RawMessage `json:"emit"`
+	}
+	if err := json.Unmarshal(blob, &msg); err != nil || len(msg.Emit) < 2 {
+		return nil
+	}
+	var sample clockSample
+	if err := json.Unmarshal(msg.Emit[1], &sample); err != nil || sample.SentAt == 0 || sample.ReceivedAt == 0 {
+		return nil
+	}
+	sample.ArrivedAt = arrivedAt.UnixMilli()
+	return &sample
+}
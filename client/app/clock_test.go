@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockEstimator(t *testing.T) {
+	var e clockEstimator
+	if e.estimate() != nil {
+		t.Fatal("estimate without samples")
+	}
+	// the host is 300ms behind the server, the second exchange had a slow return path
+	e.add(clockSample{SentAt: 1000, ReceivedAt: 1320, SentBackAt: 1321, ArrivedAt: 1041})
+	e.add(clockSample{SentAt: 2000, ReceivedAt: 2320, SentBackAt: 2321, ArrivedAt: 2241})
+	clock := e.estimate()
+	if clock.Offset != 300 || clock.Rtt != 40 {
+		t.Errorf("unexpected clock estimate: %+v", clock)
+	}
+}
+
+func TestParsePong(t *testing.T) {
+	blob := []byte(`{"emit":["node-pong",{"id":"a","sentAt":1000,"receivedAt":1320,"sentBackAt":1321}]}`)
+	sample := parsePong(blob, time.UnixMilli(1041))
+	if sample == nil || sample.offset() != 300 {
+		t.Errorf("unexpected pong sample: %+v", sample)
+	}
+	// servers before the clock exchange only send the id
+	if sample := parsePong([]byte(`{"emit":["node-pong","a"]}`), time.Now()); sample != nil {
+		t.Errorf("sample parsed from a legacy pong: %+v", sample)
+	}
+}
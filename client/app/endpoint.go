@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"ethstats/client/app/model"
+	"ethstats/client/config"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// endpointProbeTimeout bounds the check of an endpoint, so a blackholed endpoint doesn't
+	// cost the whole chain timeout
+	endpointProbeTimeout = 3 * time.Second
+	// endpointMinBackoff and endpointMaxBackoff bound the wait before an endpoint of higher
+	// priority than the active one is probed again
+	endpointMinBackoff = 15 * time.Second
+	endpointMaxBackoff = 5 * time.Minute
+)
+
+// endpoint is a configured node rpc endpoint, dialed on first use
+type endpoint struct {
+	config.Endpoint
+	client  *rpc.Client
+	backoff time.Duration // doubled on every failed probe, reset once the endpoint answers
+	retryAt time.Time
+}
+
+// endpoints picks the node rpc endpoint to use. The endpoints are probed in priority order
+// before every report, so a recovered endpoint of higher priority is used again. A failed
+// endpoint of higher priority than the active one is only probed again after a backoff
+type endpoints struct {
+	lock         sync.Mutex
+	list         []*endpoint
+	active       int
+	failovers    int
+	lastFailover int64
+	timeout      time.Duration
+	metrics      *rpcMetrics
+}
+
+// newEndpoints creates the endpoint list from the config, the single chain url is used if no
+// endpoint list is configured
+func newEndpoints(chain *config.Chain, metrics *rpcMetrics) *endpoints {
+	configs := chain.Endpoints
+	if len(configs) == 0 {
+		configs = []config.Endpoint{{Url: chain.Url}}
+	}
+	list := make([]*endpoint, 0, len(configs))
+	for _, c := range configs {
+		list = append(list, &endpoint{Endpoint: c})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Priority < list[j].Priority
+	})
+	return &endpoints{
+		list:    list,
+		active:  -1,
+		timeout: time.Duration(chain.Timeout) * time.Second,
+		metrics: metrics,
+	}
+}
+
+// get returns the client of the first healthy endpoint, and whether it differs from the
+// endpoint used before
+func (e *endpoints) get(ctx context.Context) (caller, bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var errs []string
+	now := time.Now()
+	for i, ep := range e.list {
+		if e.active >= 0 && i < e.active && now.Before(ep.retryAt) {
+			continue
+		}
+		c, err := e.probe(ctx, ep)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", redactUrl(ep.Url), err))
+			ep.backoff = min(max(2*ep.backoff, endpointMinBackoff), endpointMaxBackoff)
+			ep.retryAt = now.Add(ep.backoff)
+			continue
+		}
+		ep.backoff, ep.retryAt = 0, time.Time{}
+		changed := i != e.active
+		// falling back to a recovered endpoint of higher priority is not a failover
+		if changed && e.active >= 0 && i > e.active {
+			e.failovers++
+			e.lastFailover = now.Unix()
+		}
+		e.active = i
+		return c, changed, nil
+	}
+	return nil, false, errors.New("no rpc endpoint available, " + strings.Join(errs, "; "))
+}
+
+// probe dials the endpoint if needed and checks it answers within the probe timeout, a failed
+// endpoint is closed so it is dialed again on the next probe
+func (e *endpoints) probe(ctx context.Context, ep *endpoint) (caller, error) {
+	timeout := endpointProbeTimeout
+	if e.timeout > 0 && e.timeout < timeout {
+		timeout = e.timeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if ep.client == nil {
+		client, err := dialEndpoint(probeCtx, ep.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		ep.client = client
+	}
+	c := e.metrics.wrap(&timeoutClient{client: ep.client, timeout: e.timeout}, "")
+	var number hexutil.Uint64
+	if err := c.CallContext(probeCtx, &number, "eth_blockNumber"); err != nil {
+		ep.client.Close()
+		ep.client = nil
+		return nil, err
+	}
+	return c, nil
+}
+
+func (e *endpoints) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, e.timeout)
+}
+
+// url returns the url of the active endpoint, or of the preferred one before the first probe
+func (e *endpoints) url() string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.active < 0 {
+		return e.list[0].Url
+	}
+	return e.list[e.active].Url
+}
+
+// status returns the active endpoint and the failover count
+func (e *endpoints) status() *model.Endpoint {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.active < 0 {
+		return nil
+	}
+	return &model.Endpoint{
+		Url:          redactUrl(e.list[e.active].Url),
+		Priority:     e.list[e.active].Priority,
+		Total:        len(e.list),
+		Failovers:    e.failovers,
+		LastFailover: e.lastFailover,
+	}
+}
+
+// dialEndpoint connects to the endpoint with its authentication, an url without scheme is
+// an ipc socket path
+func dialEndpoint(ctx context.Context, ep config.Endpoint) (*rpc.Client, error) {
+	var options []rpc.ClientOption
+	for key, value := range ep.Headers {
+		options = append(options, rpc.WithHeader(key, value))
+	}
+	switch {
+	case ep.JwtSecret != "":
+		secret, err := readJwtSecret(ep.JwtSecret)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, rpc.WithHTTPAuth(jwtAuth(secret)))
+	case ep.Username != "":
+		options = append(options, rpc.WithHTTPAuth(basicAuth(ep.Username, ep.Password)))
+	}
+	return rpc.DialOptions(ctx, ep.Url, options...)
+}
+
+// readJwtSecret reads the hex encoded 32 bytes secret, the format used by the execution clients
+func readJwtSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid jwt secret in %s, want 32 hex encoded bytes", path)
+	}
+	return secret, nil
+}
+
+// jwtAuth signs a fresh HS256 token for every request, the nodes only accept tokens issued
+// within a few seconds
+func jwtAuth(secret []byte) rpc.HTTPAuth {
+	return func(h http.Header) error {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+		claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iat":%d}`, time.Now().Unix())))
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(header + "." + claims))
+		signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		h.Set("Authorization", "Bearer "+header+"."+claims+"."+signature)
+		return nil
+	}
+}
+
+func basicAuth(username, password string) rpc.HTTPAuth {
+	return func(h http.Header) error {
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+		return nil
+	}
+}
+
+// redactUrl hides the password of the url, ipc paths are returned as is
+func redactUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme == "" {
+		return rawUrl
+	}
+	return u.Redacted()
+}
+
+// timeoutClient bounds every call by the configured chain timeout
+type timeoutClient struct {
+	client  *rpc.Client
+	timeout time.Duration
+}
+
+func (t *timeoutClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	return t.client.CallContext(ctx, result, method, args...)
+}
+
+func (t *timeoutClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	return t.client.BatchCallContext(ctx, b)
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"sort"
+)
+
+const (
+	defaultFeeBlocks = 20
+)
+
+var defaultFeePercentiles = []float64{10, 50, 90}
+
+// rpcFeeHistory is the raw eth_feeHistory result, including the blob fields added by EIP-4844
+type rpcFeeHistory struct {
+	OldestBlock      *hexutil.Big     `json:"oldestBlock"`
+	Reward           [][]*hexutil.Big `json:"reward"`
+	BaseFee          []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio     []float64        `json:"gasUsedRatio"`
+	BlobBaseFee      []*hexutil.Big   `json:"baseFeePerBlobGas"`
+	BlobGasUsedRatio []float64        `json:"blobGasUsedRatio"`
+}
+
+// fetchFee requests the fee history of the latest blocks and summarizes it
+func fetchFee(ctx context.Context, c caller, blocks uint64, percentiles []float64) (*model.Fee, error) {
+	if blocks == 0 {
+		blocks = defaultFeeBlocks
+	}
+	if len(percentiles) == 0 {
+		percentiles = defaultFeePercentiles
+	}
+	var raw rpcFeeHistory
+	if err := c.CallContext(ctx, &raw, "eth_feeHistory", hexutil.Uint64(blocks), rpc.LatestBlockNumber, percentiles); err != nil {
+		return nil, err
+	}
+	return raw.toModel(percentiles), nil
+}
+
+func (f *rpcFeeHistory) toModel(percentiles []float64) *model.Fee {
+	fee := &model.Fee{
+		Percentiles:      percentiles,
+		GasUsedRatio:     f.GasUsedRatio,
+		BlobGasUsedRatio: f.BlobGasUsedRatio,
+	}
+	if f.OldestBlock != nil {
+		fee.OldestBlock = f.OldestBlock.ToInt().Uint64()
+		if len(f.GasUsedRatio) > 0 {
+			fee.LatestBlock = fee.OldestBlock + uint64(len(f.GasUsedRatio)) - 1
+		}
+	}
+	// the base fee list has one more entry than the blocks, which is the next block's base fee
+	for i, baseFee := range f.BaseFee {
+		if i == len(f.GasUsedRatio) {
+			fee.NextBaseFee = (*math.Decimal256)(baseFee)
+			break
+		}
+		fee.BaseFee = append(fee.BaseFee, (*math.Decimal256)(baseFee))
+	}
+	if len(f.BlobBaseFee) > 0 {
+		fee.BlobBaseFee = (*math.Decimal256)(f.BlobBaseFee[len(f.BlobBaseFee)-1])
+	}
+	for i := range percentiles {
+		var rewards []*big.Int
+		for _, reward := range f.Reward {
+			if i < len(reward) && reward[i] != nil {
+				rewards = append(rewards, reward[i].ToInt())
+			}
+		}
+		fee.PriorityFee = append(fee.PriorityFee, (*math.Decimal256)(medianBig(rewards)))
+	}
+	return fee
+}
+
+// medianBig returns the median of the values, or nil if there are none
+func medianBig(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+	return values[len(values)/2]
+}
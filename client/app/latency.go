@@ -0,0 +1,77 @@
+package app
+
+import (
+	"ethstats/client/app/model"
+	"sort"
+)
+
+// latencyWindow is the number of latest pings the latency quality is computed from
+const latencyWindow = 60
+
+// latencySample is a ping round-trip in milliseconds, or a timed out ping
+type latencySample struct {
+	rtt     int64
+	timeout bool
+}
+
+// latencyTracker keeps a rolling window of the server round-trips
+type latencyTracker struct {
+	samples []latencySample
+}
+
+func (l *latencyTracker) add(rtt int64) {
+	l.push(latencySample{rtt: rtt})
+}
+
+func (l *latencyTracker) addTimeout() {
+	l.push(latencySample{timeout: true})
+}
+
+func (l *latencyTracker) push(sample latencySample) {
+	l.samples = append(l.samples, sample)
+	if len(l.samples) > latencyWindow {
+		l.samples = l.samples[len(l.samples)-latencyWindow:]
+	}
+}
+
+// quality computes the latency stats of the window, timed out pings only count in the ratio
+func (l *latencyTracker) quality() *model.LatencyQuality {
+	if len(l.samples) == 0 {
+		return nil
+	}
+	quality := &model.LatencyQuality{Samples: len(l.samples)}
+	var rtts []int64
+	var sum, jitter int64
+	timeouts := 0
+	for _, sample := range l.samples {
+		if sample.timeout {
+			timeouts++
+			continue
+		}
+		if len(rtts) > 0 {
+			diff := sample.rtt - rtts[len(rtts)-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			jitter += diff
+		}
+		rtts = append(rtts, sample.rtt)
+		sum += sample.rtt
+	}
+	quality.TimeoutRatio = float64(timeouts) / float64(len(l.samples))
+	if len(rtts) == 0 {
+		return quality
+	}
+	quality.Avg = float64(sum) / float64(len(rtts))
+	if len(rtts) > 1 {
+		quality.Jitter = float64(jitter) / float64(len(rtts)-1)
+	}
+	sort.Slice(rtts, func(i, j int) bool {
+		return rtts[i] < rtts[j]
+	})
+	quality.Min = rtts[0]
+	quality.Max = rtts[len(rtts)-1]
+	index := (len(rtts)*95+99)/100 - 1
+	quality.P95 = rtts[index]
+	return quality
+}
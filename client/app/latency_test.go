@@ -0,0 +1,27 @@
+package app
+
+import "testing"
+
+func TestLatencyQuality(t *testing.T) {
+	var l latencyTracker
+	if l.quality() != nil {
+		t.Fatal("quality without samples")
+	}
+	for _, rtt := range []int64{10, 30, 20, 40} {
+		l.add(rtt)
+	}
+	l.addTimeout()
+	q := l.quality()
+	if q.Samples != 5 || q.Min != 10 || q.Max != 40 || q.Avg != 25 || q.P95 != 40 {
+		t.Errorf("unexpected latency quality: %+v", q)
+	}
+	if q.Jitter != 50.0/3 || q.TimeoutRatio != 0.2 {
+		t.Errorf("unexpected jitter or timeout ratio: %+v", q)
+	}
+	for i := 0; i < latencyWindow; i++ {
+		l.add(5)
+	}
+	if q := l.quality(); q.Samples != latencyWindow || q.TimeoutRatio != 0 {
+		t.Errorf("window not bounded: %+v", q)
+	}
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/rpc"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rpcMetricsWindow is the number of latest calls per method the metrics are computed from
+const rpcMetricsWindow = 200
+
+// caller is the rpc client used by the stats collectors
+type caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+type rpcSample struct {
+	duration time.Duration
+	failed   bool
+	timeout  bool
+	batched  bool // sent in a batch, its own duration is unknown
+}
+
+// rpcMetrics keeps a rolling window of the rpc calls of every method
+type rpcMetrics struct {
+	lock    sync.Mutex
+	methods map[string][]rpcSample
+}
+
+func newRpcMetrics() *rpcMetrics {
+	return &rpcMetrics{methods: make(map[string][]rpcSample)}
+}
+
+// wrap returns a caller timing every call of the client, prefix tells apart the methods of
+// other endpoints than the node, like the L1 of a rollup
+func (m *rpcMetrics) wrap(c caller, prefix string) caller {
+	return &meteredClient{client: c, metrics: m, prefix: prefix}
+}
+
+// rpcBatch is the method the durations of the batches are recorded under
+const rpcBatch = "batch"
+
+// rpcMethodNotFound is the error code of a method the node doesn't serve
+const rpcMethodNotFound = -32601
+
+func (m *rpcMetrics) record(method string, duration time.Duration, err error) {
+	m.add(method, rpcSample{duration: duration}, err)
+}
+
+// recordBatched records the result of a call sent in a batch, it counts in the error rate of
+// the method but not in its latency
+func (m *rpcMetrics) recordBatched(method string, err error) {
+	m.add(method, rpcSample{batched: true}, err)
+}
+
+func (m *rpcMetrics) add(method string, sample rpcSample, err error) {
+	sample.failed = rpcFailed(err)
+	if sample.failed {
+		var netErr net.Error
+		sample.timeout = errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	samples := append(m.methods[method], sample)
+	if len(samples) > rpcMetricsWindow {
+		samples = samples[len(samples)-rpcMetricsWindow:]
+	}
+	m.methods[method] = samples
+}
+
+// rpcFailed reports whether the call failed. The optional methods not served by the node and
+// the reverted calls, such as the batch lookups of arbitrum, are answers of a healthy node
+func rpcFailed(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFound {
+		return false
+	}
+	return err != nil && !rpcReverted(err)
+}
+
+// report computes the error rate and the latency percentiles of every method
+func (m *rpcMetrics) report() []model.RpcMetric {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	metrics := make([]model.RpcMetric, 0, len(m.methods))
+	for method, samples := range m.methods {
+		metric := model.RpcMetric{Method: method, Calls: len(samples)}
+		durations := make([]float64, 0, len(samples))
+		for _, sample := range samples {
+			if sample.failed {
+				metric.Errors++
+			}
+			if sample.timeout {
+				metric.Timeouts++
+			}
+			if !sample.batched {
+				durations = append(durations, float64(sample.duration.Microseconds())/1000)
+			}
+		}
+		sort.Float64s(durations)
+		metric.ErrorRate = float64(metric.Errors) / float64(metric.Calls)
+		metric.P50 = percentile(durations, 50)
+		metric.P95 = percentile(durations, 95)
+		metric.P99 = percentile(durations, 99)
+		metrics = append(metrics, metric)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Method < metrics[j].Method
+	})
+	return metrics
+}
+
+// percentile returns the nearest-rank percentile of the sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(p/100*float64(len(sorted))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// meteredClient records the duration and the result of every call
+type meteredClient struct {
+	client  caller
+	metrics *rpcMetrics
+	prefix  string
+}
+
+func (m *meteredClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	start := time.Now()
+	err := m.client.CallContext(ctx, result, method, args...)
+	m.metrics.record(m.prefix+method, time.Since(start), err)
+	return err
+}
+
+// BatchCallContext records the duration of the whole batch under its own method, and the
+// result of every element under its method
+func (m *meteredClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	start := time.Now()
+	err := m.client.BatchCallContext(ctx, b)
+	m.metrics.record(m.prefix+rpcBatch, time.Since(start), err)
+	for _, elem := range b {
+		elemErr := err
+		if elemErr == nil {
+			elemErr = elem.Error
+		}
+		m.metrics.recordBatched(m.prefix+elem.Method, elemErr)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/rpc"
+	"testing"
+	"time"
+)
+
+func TestRpcMetrics(t *testing.T) {
+	metrics := newRpcMetrics()
+	for i := 1; i <= 100; i++ {
+		metrics.record("eth_blockNumber", time.Duration(i)*time.Millisecond, nil)
+	}
+	metrics.record("eth_gasPrice", time.Millisecond, errors.New("internal error"))
+	metrics.record("eth_gasPrice", time.Second, context.DeadlineExceeded)
+	for i := 0; i < rpcMetricsWindow+10; i++ {
+		metrics.record("net_peerCount", time.Millisecond, nil)
+	}
+
+	report := metrics.report()
+	if len(report) != 3 {
+		t.Fatalf("method count mismatch: have %d, want 3", len(report))
+	}
+	block, gas, peer := report[0], report[1], report[2]
+	if block.Calls != 100 || block.P50 != 50 || block.P95 != 95 || block.P99 != 99 {
+		t.Errorf("unexpected eth_blockNumber metric: %+v", block)
+	}
+	if gas.Errors != 2 || gas.Timeouts != 1 || gas.ErrorRate != 1 {
+		t.Errorf("unexpected eth_gasPrice metric: %+v", gas)
+	}
+	if peer.Calls != rpcMetricsWindow {
+		t.Errorf("window not bounded: have %d calls", peer.Calls)
+	}
+}
+
+func TestRpcMetricsExpectedErrors(t *testing.T) {
+	metrics := newRpcMetrics()
+	for i := 0; i < 10; i++ {
+		metrics.record("txpool_status", time.Millisecond, &testRpcError{code: rpcMethodNotFound, msg: "the method txpool_status does not exist/is not available"})
+		metrics.record("eth_call", time.Millisecond, &testRpcError{code: 3, msg: "execution reverted"})
+		metrics.record("eth_gasPrice", time.Millisecond, &testRpcError{code: -32000, msg: "internal error"})
+	}
+	// an optional method the node doesn't serve must not reach the error rate of the rpc alert
+	for _, metric := range metrics.report() {
+		failing := metric.Method == "eth_gasPrice"
+		if (metric.ErrorRate > 0) != failing || metric.Calls != 10 {
+			t.Errorf("unexpected %s metric: %+v", metric.Method, metric)
+		}
+	}
+}
+
+// testSlowBatchCaller answers the batches after a delay, failing eth_gasPrice
+type testSlowBatchCaller struct {
+	delay time.Duration
+}
+
+func (c *testSlowBatchCaller) CallContext(context.Context, interface{}, string, ...interface{}) error {
+	return nil
+}
+
+func (c *testSlowBatchCaller) BatchCallContext(_ context.Context, b []rpc.BatchElem) error {
+	time.Sleep(c.delay)
+	for i := range b {
+		if b[i].Method == "eth_gasPrice" {
+			b[i].Error = errors.New("internal error")
+		}
+	}
+	return nil
+}
+
+func TestRpcMetricsBatch(t *testing.T) {
+	metrics := newRpcMetrics()
+	c := metrics.wrap(&testSlowBatchCaller{delay: 20 * time.Millisecond}, "")
+	if err := c.CallContext(context.Background(), nil, "eth_blockNumber"); err != nil {
+		t.Fatal(err)
+	}
+	batch := []rpc.BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_gasPrice"}}
+	if err := c.BatchCallContext(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	report := metrics.report()
+	if len(report) != 3 {
+		t.Fatalf("method count mismatch: have %d, want 3", len(report))
+	}
+	batched, block, gas := report[0], report[1], report[2]
+	if batched.Method != rpcBatch || batched.Calls != 1 || batched.P95 < 20 {
+		t.Errorf("unexpected batch metric: %+v", batched)
+	}
+	// the batch duration must not count as the latency of its methods
+	if block.Calls != 2 || block.P99 >= 20 {
+		t.Errorf("unexpected eth_blockNumber metric: %+v", block)
+	}
+	if gas.Calls != 1 || gas.Errors != 1 || gas.P95 != 0 {
+		t.Errorf("unexpected eth_gasPrice metric: %+v", gas)
+	}
+}
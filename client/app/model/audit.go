@@ -0,0 +1,21 @@
+package model
+
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Audit is the result of the latest rpc exposure audit
+type Audit struct {
+	Time     int64 //审计时间，unix秒
+	Findings []AuditFinding
+}
+
+// AuditFinding is a risk found by the audit
+type AuditFinding struct {
+	Check    string //检查项：namespace、accounts、rpc-exposed、engine-jwt
+	Severity string
+	Message  string
+}
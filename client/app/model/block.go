@@ -1,12 +1,23 @@
 package model
 
-import "github.com/ethereum/go-ethereum/core/types"
+import "github.com/ethereum/go-ethereum/common/math"
 
+// Block is the summary of the latest block header seen by the node.
+// Big integers are encoded as decimal strings, so they survive any json decoder
 type Block struct {
-	Number       uint64
-	Hash         string
-	Difficulty   uint64
-	Transactions []*types.Transaction
-	Uncles       []*types.Header
-	Time         uint64
+	Number          uint64
+	Hash            string
+	ParentHash      string
+	Miner           string           //出块地址，合并后为fee recipient
+	Difficulty      *math.Decimal256 //合并后恒为0
+	GasUsed         uint64
+	GasLimit        uint64
+	BaseFee         *math.Decimal256 //EIP-1559，之前的块为空
+	TxCount         int
+	UncleCount      int
+	WithdrawalCount int     //EIP-4895，上海升级之前为0
+	BlobGasUsed     *uint64 //EIP-4844，坎昆升级之前为空
+	ExcessBlobGas   *uint64 //EIP-4844，坎昆升级之前为空
+	Size            uint64
+	Time            uint64
 }
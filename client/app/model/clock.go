@@ -0,0 +1,8 @@
+package model
+
+// Clock is the clock offset of the host to the server, estimated NTP-style from the ping/pong
+type Clock struct {
+	Offset int64 //毫秒，服务端时间减本机时间，正数表示本机时钟偏慢
+	Rtt    int64 //毫秒，估算所用样本的往返时间
+	Time   int64 //估算时间，unix秒
+}
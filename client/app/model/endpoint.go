@@ -0,0 +1,10 @@
+package model
+
+// Endpoint is the node rpc endpoint in use
+type Endpoint struct {
+	Url          string //隐藏了basic auth密码
+	Priority     int
+	Total        int   //配置的地址数量
+	Failovers    int   //启动以来的切换次数
+	LastFailover int64 //最近一次切换的时间，unix秒
+}
@@ -0,0 +1,17 @@
+package model
+
+import "github.com/ethereum/go-ethereum/common/math"
+
+// Fee is the fee market report built from eth_feeHistory over the latest blocks.
+// Prices are in wei and encoded as decimal strings
+type Fee struct {
+	OldestBlock      uint64
+	LatestBlock      uint64
+	BaseFee          []*math.Decimal256 //每个块的base fee，从旧到新
+	NextBaseFee      *math.Decimal256   //下一个块的base fee
+	Percentiles      []float64          //priority fee的百分位
+	PriorityFee      []*math.Decimal256 //与Percentiles一一对应，取所有块的中位数
+	GasUsedRatio     []float64
+	BlobBaseFee      *math.Decimal256 //下一个块的blob base fee，坎昆升级之前为空
+	BlobGasUsedRatio []float64
+}
@@ -0,0 +1,9 @@
+package model
+
+// Finality is the safe and finalized block of the node, empty before the merge
+type Finality struct {
+	Safe          uint64
+	SafeHash      string
+	Finalized     uint64
+	FinalizedHash string
+}
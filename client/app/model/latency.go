@@ -0,0 +1,12 @@
+package model
+
+// LatencyQuality is the quality of the server round-trips over the latest pings, in milliseconds
+type LatencyQuality struct {
+	Samples      int //窗口内的ping次数，包含超时
+	Min          int64
+	Avg          float64
+	Max          int64
+	Jitter       float64 //相邻两次往返时间差的平均值
+	P95          int64
+	TimeoutRatio float64 //超时的ping占比，0~1
+}
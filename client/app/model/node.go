@@ -1,16 +1,25 @@
 package model
 
 type Node struct {
-	Id         string
-	Name       string //名称
-	Contact    string //联系方式
-	Coinbase   string //账户地址
-	Node       string //节点
-	Net        string //网络
-	Protocol   string //协议
-	Api        string //接口
-	ChainPort  string //端口
-	OSPlatform string //平台
-	OS         string //系统
-	Client     string //客户端
+	Id            string
+	Name          string   //名称
+	Contact       string   //联系方式
+	Coinbase      string   //账户地址
+	Node          string   //节点enode
+	Enr           string   //节点ENR
+	NodeId        string   //节点node id
+	Net           string   //网络ID
+	ChainId       string   //链ID
+	Genesis       string   //创世块hash
+	Protocol      string   //协议，多个逗号隔开
+	Api           string   //开放的rpc接口，多个逗号隔开
+	ChainPort     string   //端口
+	DiscoveryPort string   //节点发现端口
+	OSPlatform    string   //平台
+	OS            string   //系统
+	Client        string   //客户端，web3_clientVersion，如：Geth/v1.13.5-stable/linux-amd64/go1.21.4
+	ClientName    string   //客户端名称，如：Geth
+	ClientVersion string   //客户端版本，如：v1.13.5-stable
+	Agent         string   //ethstats client版本
+	Labels        []string //节点标签
 }
@@ -0,0 +1,18 @@
+package model
+
+// Peers is the peer list of the node from admin_peers
+type Peers struct {
+	Self string //本节点的node id，服务端据此判断被监控节点之间是否直连
+	List []Peer
+}
+
+// Peer is a connected peer of the node
+type Peer struct {
+	Id         string         //node id
+	RemoteAddr string         //远端地址
+	Name       string         //完整的客户端名称，如：Geth/v1.13.5-stable/linux-amd64/go1.21.4
+	Client     string         //客户端，如：Geth
+	Version    string         //客户端版本，如：v1.13.5-stable
+	Inbound    bool           //是否为对方主动连接
+	Protocols  map[string]int //协议及版本，如：eth:68
+}
@@ -0,0 +1,16 @@
+package model
+
+// Rollup is the L2 specific health reported by the chain adapter, the heights are L2 blocks
+// unless prefixed by L1
+type Rollup struct {
+	Type         string //optimism、arbitrum
+	UnsafeL2     uint64 //最新的L2块
+	SafeL2       uint64 //已由L1数据推导出的L2块
+	FinalizedL2  uint64
+	L1Head       uint64 //L1最新块
+	L1Origin     uint64 //optimism：safe L2块的L1 origin；arbitrum：最新L2块对应的L1块
+	L1Lag        uint64 //L1Head-L1Origin，L2到L1的推导延迟，单位块
+	BatchL2      uint64 //arbitrum：已提交batch的最新L2块
+	BatchLag     uint64 //arbitrum：UnsafeL2-BatchL2，单位块
+	BatchLagTime uint64 //arbitrum：batch延迟，单位秒
+}
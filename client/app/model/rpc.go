@@ -0,0 +1,13 @@
+package model
+
+// RpcMetric is the health of a rpc method over the latest calls
+type RpcMetric struct {
+	Method    string
+	Calls     int //统计窗口内的调用次数
+	Errors    int //包含超时
+	Timeouts  int
+	ErrorRate float64
+	P50       float64 //毫秒
+	P95       float64
+	P99       float64
+}
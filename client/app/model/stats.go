@@ -1,11 +1,32 @@
 package model
 
+import "github.com/ethereum/go-ethereum/common/math"
+
+const (
+	StatusOK           = "ok"
+	StatusWrongNetwork = "wrong-network" //链ID、网络ID或创世块与配置不一致
+)
+
 type Stats struct {
+	Status    string
+	Mismatch  []string //与配置不一致的项
 	Active    bool
 	PeerCount uint64
+	Peers     *Peers //需要开启peers配置
 	Pending   uint
-	GasPrice  int64
+	Txpool    *Txpool          //节点未开放txpool接口时为空
+	GasPrice  *math.Decimal256 //wei
+	Fee       *Fee
 	Syncing   bool
+	Sync      *SyncProgress //同步完成后为空
+	ChainId   string        //链ID，服务端按链汇总区块数据
 	NodeInfo  Node
 	Block     *Block
+	Finality  *Finality         //合并之前的链为空
+	Security  *Audit            //rpc安全审计结果
+	Rollup    *Rollup           //L2链的rollup状态，由chain.type决定
+	Rpc       []RpcMetric       //节点rpc各方法的耗时及错误率
+	Endpoint  *Endpoint         //当前使用的rpc地址
+	Errors    map[string]string //请求失败的字段及错误信息，失败的字段为空
+	Clock     *Clock            //本机与服务端的时钟偏差
 }
@@ -0,0 +1,21 @@
+package model
+
+// SyncProgress is the eth_syncing progress of the node, the state fields depend on the
+// sync mode and the client, unsupported ones stay 0
+type SyncProgress struct {
+	StartingBlock uint64
+	CurrentBlock  uint64
+	HighestBlock  uint64
+
+	PulledStates uint64 //fast sync
+	KnownStates  uint64
+
+	SyncedAccounts  uint64 //snap sync
+	SyncedBytecodes uint64
+	SyncedStorage   uint64
+
+	HealedTrienodes  uint64 //snap sync healing
+	HealedBytecodes  uint64
+	HealingTrienodes uint64
+	HealingBytecode  uint64
+}
@@ -0,0 +1,24 @@
+package model
+
+import "github.com/ethereum/go-ethereum/common/math"
+
+// Txpool is the mempool state of the node from txpool_status
+type Txpool struct {
+	Pending uint64
+	Queued  uint64
+	Inspect *TxpoolInspect //需要开启txpoolInspect配置
+}
+
+// TxpoolInspect is the summary of txpool_inspect
+type TxpoolInspect struct {
+	TopSenders          []TxpoolSender     //交易数最多的发送者
+	GasPricePercentiles []float64          //gas price分布的百分位
+	GasPrice            []*math.Decimal256 //与GasPricePercentiles一一对应，单位wei
+}
+
+// TxpoolSender is the pending and queued transaction count of a sender
+type TxpoolSender struct {
+	Address string
+	Pending int
+	Queued  int
+}
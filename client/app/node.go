@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"ethstats/client/app/model"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rpcNodeInfo is the admin_nodeInfo result
+type rpcNodeInfo struct {
+	ID    string `json:"id"`
+	Enode string `json:"enode"`
+	ENR   string `json:"enr"`
+	Ports struct {
+		Discovery int `json:"discovery"`
+		Listener  int `json:"listener"`
+	} `json:"ports"`
+	Protocols map[string]interface{} `json:"protocols"`
+}
+
+// fetchNodeIdentity discovers the identity of the node. The chain id is required, the other
+// fields are filled as far as the node exposes them, admin_nodeInfo needs the admin namespace
+func fetchNodeIdentity(ctx context.Context, c caller, node *model.Node) error {
+	var chainId hexutil.Big
+	if err := c.CallContext(ctx, &chainId, "eth_chainId"); err != nil {
+		return err
+	}
+	node.ChainId = chainId.ToInt().String()
+
+	var clientVersion string
+	if err := c.CallContext(ctx, &clientVersion, "web3_clientVersion"); err == nil {
+		node.Client = clientVersion
+		node.ClientName, node.ClientVersion = parseClientName(clientVersion)
+	}
+	var netVersion string
+	if err := c.CallContext(ctx, &netVersion, "net_version"); err == nil {
+		node.Net = netVersion
+	}
+	var genesis struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := c.CallContext(ctx, &genesis, "eth_getBlockByNumber", rpc.EarliestBlockNumber, false); err == nil {
+		node.Genesis = genesis.Hash.String()
+	}
+	var coinbase common.Address
+	if err := c.CallContext(ctx, &coinbase, "eth_coinbase"); err == nil {
+		node.Coinbase = coinbase.String()
+	}
+	var modules map[string]string
+	if err := c.CallContext(ctx, &modules, "rpc_modules"); err == nil {
+		node.Api = joinKeys(modules)
+	}
+	var info rpcNodeInfo
+	if err := c.CallContext(ctx, &info, "admin_nodeInfo"); err == nil {
+		node.NodeId = info.ID
+		node.Node = info.Enode
+		node.Enr = info.ENR
+		node.Protocol = joinKeys(info.Protocols)
+		if info.Ports.Listener > 0 {
+			node.ChainPort = strconv.Itoa(info.Ports.Listener)
+		}
+		if info.Ports.Discovery > 0 {
+			node.DiscoveryPort = strconv.Itoa(info.Ports.Discovery)
+		}
+	}
+	return nil
+}
+
+// verifyNetwork compares the node identity with the expected network of the config, an
+// empty expectation or an unknown node value is not checked
+func verifyNetwork(node *model.Node, chainId, networkId, genesis string) []string {
+	var mismatch []string
+	check := func(name, expected, actual string) {
+		if expected != "" && actual != "" && !strings.EqualFold(expected, actual) {
+			mismatch = append(mismatch, fmt.Sprintf("%s: expected %s, got %s", name, expected, actual))
+		}
+	}
+	check("chainId", chainId, node.ChainId)
+	check("networkId", networkId, node.Net)
+	check("genesis", genesis, node.Genesis)
+	return mismatch
+}
+
+// joinKeys joins the sorted keys of the map with comma
+func joinKeys[T any](m map[string]T) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"ethstats/client/app/model"
+)
+
+// opL1BlockRef and opL2BlockRef are the block references of optimism_syncStatus
+type opL1BlockRef struct {
+	Number uint64 `json:"number"`
+}
+
+type opL2BlockRef struct {
+	Number   uint64       `json:"number"`
+	L1Origin opL1BlockRef `json:"l1origin"`
+}
+
+type opSyncStatus struct {
+	HeadL1      opL1BlockRef `json:"head_l1"`
+	UnsafeL2    opL2BlockRef `json:"unsafe_l2"`
+	SafeL2      opL2BlockRef `json:"safe_l2"`
+	FinalizedL2 opL2BlockRef `json:"finalized_l2"`
+}
+
+// optimismAdapter reports the OP Stack heads from optimism_syncStatus of the rollup node (op-node)
+type optimismAdapter struct {
+	rollupNode *lazyClient
+}
+
+func (o *optimismAdapter) rollup(ctx context.Context, _ caller) (*model.Rollup, error) {
+	c, err := o.rollupNode.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var status opSyncStatus
+	if err = c.CallContext(ctx, &status, "optimism_syncStatus"); err != nil {
+		return nil, err
+	}
+	rollup := &model.Rollup{
+		Type:        chainTypeOptimism,
+		UnsafeL2:    status.UnsafeL2.Number,
+		SafeL2:      status.SafeL2.Number,
+		FinalizedL2: status.FinalizedL2.Number,
+		L1Head:      status.HeadL1.Number,
+		L1Origin:    status.SafeL2.L1Origin.Number,
+	}
+	if rollup.L1Head > rollup.L1Origin {
+		rollup.L1Lag = rollup.L1Head - rollup.L1Origin
+	}
+	return rollup, nil
+}
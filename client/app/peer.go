@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"ethstats/client/app/model"
+	"strings"
+)
+
+// rpcPeer is the admin_peers entry, only the fields we report are decoded
+type rpcPeer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Network struct {
+		RemoteAddress string `json:"remoteAddress"`
+		Inbound       bool   `json:"inbound"`
+	} `json:"network"`
+	Protocols map[string]interface{} `json:"protocols"`
+}
+
+// fetchPeers requests the peer list by admin_peers, the admin namespace must be exposed.
+// self is the node id of the monitored node
+func fetchPeers(ctx context.Context, c caller, self string) (*model.Peers, error) {
+	var infos []*rpcPeer
+	if err := c.CallContext(ctx, &infos, "admin_peers"); err != nil {
+		return nil, err
+	}
+	peers := &model.Peers{
+		Self: self,
+		List: make([]model.Peer, 0, len(infos)),
+	}
+	for _, info := range infos {
+		client, version := parseClientName(info.Name)
+		peer := model.Peer{
+			Id:         info.ID,
+			RemoteAddr: info.Network.RemoteAddress,
+			Name:       info.Name,
+			Client:     client,
+			Version:    version,
+			Inbound:    info.Network.Inbound,
+			Protocols:  make(map[string]int),
+		}
+		for name, protocol := range info.Protocols {
+			// the protocol is "handshake" before the status exchange finished
+			if detail, ok := protocol.(map[string]interface{}); ok {
+				if version, ok := detail["version"].(float64); ok {
+					peer.Protocols[name] = int(version)
+				}
+			}
+		}
+		peers.List = append(peers.List, peer)
+	}
+	return peers, nil
+}
+
+// parseClientName splits the client and version from a name like
+// Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4, some clients insert a custom
+// identity in the second segment, so the version is the first segment starting with v
+func parseClientName(name string) (client, version string) {
+	parts := strings.Split(name, "/")
+	client = parts[0]
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, "v") && len(part) > 1 && part[1] >= '0' && part[1] <= '9' {
+			return client, part
+		}
+	}
+	if len(parts) > 1 {
+		version = parts[1]
+	}
+	return client, version
+}
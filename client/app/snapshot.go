@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// snapshot is the core node state, gathered in one batch request
+type snapshot struct {
+	PeerCount uint64
+	GasPrice  *math.Decimal256
+	Sync      *model.SyncProgress
+	Block     *model.Block
+	Finality  *model.Finality
+	Pending   uint
+}
+
+// fetchSnapshot requests the core stats in one round-trip. A failing method only leaves its
+// field empty, the errors are returned per stats field
+func fetchSnapshot(ctx context.Context, c caller) (*snapshot, map[string]string) {
+	var (
+		peerCount hexutil.Uint64
+		gasPrice  *hexutil.Big
+		syncing   json.RawMessage
+		latest    *rpcBlock
+		safe      *rpcBlock
+		finalized *rpcBlock
+		pending   hexutil.Uint
+	)
+	batch := []rpc.BatchElem{
+		{Method: "net_peerCount", Result: &peerCount},
+		{Method: "eth_gasPrice", Result: &gasPrice},
+		{Method: "eth_syncing", Result: &syncing},
+		{Method: "eth_getBlockByNumber", Args: []interface{}{rpc.LatestBlockNumber, false}, Result: &latest},
+		{Method: "eth_getBlockByNumber", Args: []interface{}{rpc.SafeBlockNumber, false}, Result: &safe},
+		{Method: "eth_getBlockByNumber", Args: []interface{}{rpc.FinalizedBlockNumber, false}, Result: &finalized},
+		{Method: "eth_getBlockTransactionCountByNumber", Args: []interface{}{rpc.PendingBlockNumber}, Result: &pending},
+	}
+	fields := []string{"PeerCount", "GasPrice", "Sync", "Block", "Finality", "Finality", "Pending"}
+
+	errs := make(map[string]string)
+	if err := c.BatchCallContext(ctx, batch); err != nil {
+		for _, field := range fields {
+			errs[field] = err.Error()
+		}
+		return &snapshot{}, errs
+	}
+	for i, elem := range batch {
+		if elem.Error != nil && errs[fields[i]] == "" {
+			errs[fields[i]] = elem.Error.Error()
+		}
+	}
+
+	snap := &snapshot{}
+	if errs["PeerCount"] == "" {
+		snap.PeerCount = uint64(peerCount)
+	}
+	if errs["GasPrice"] == "" && gasPrice != nil {
+		snap.GasPrice = (*math.Decimal256)(gasPrice)
+	}
+	if errs["Sync"] == "" {
+		sync, err := parseSyncProgress(syncing)
+		if err != nil {
+			errs["Sync"] = err.Error()
+		}
+		snap.Sync = sync
+	}
+	if errs["Block"] == "" {
+		if latest == nil {
+			errs["Block"] = errBlockNotFound.Error()
+		} else {
+			snap.Block = latest.toModel()
+		}
+	}
+	if errs["Finality"] == "" {
+		if safe == nil || finalized == nil {
+			errs["Finality"] = errBlockNotFound.Error()
+		} else {
+			snap.Finality = &model.Finality{
+				Safe:          uint64(safe.Number),
+				SafeHash:      safe.Hash.String(),
+				Finalized:     uint64(finalized.Number),
+				FinalizedHash: finalized.Hash.String(),
+			}
+		}
+	}
+	if errs["Pending"] == "" {
+		snap.Pending = uint(pending)
+	}
+	return snap, errs
+}
+
+var errBlockNotFound = errors.New("block not found")
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"testing"
+)
+
+type testSnapshotService struct{}
+
+func (s *testSnapshotService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(hexutil.MustDecodeBig("0x3b9aca00"))
+}
+
+func (s *testSnapshotService) Syncing() bool {
+	return false
+}
+
+func (s *testSnapshotService) GetBlockByNumber(tag rpc.BlockNumber, full bool) (map[string]interface{}, error) {
+	if tag == rpc.SafeBlockNumber || tag == rpc.FinalizedBlockNumber {
+		return nil, errors.New("unknown block")
+	}
+	return map[string]interface{}{"number": hexutil.Uint64(100), "gasLimit": hexutil.Uint64(30000000)}, nil
+}
+
+func (s *testSnapshotService) GetBlockTransactionCountByNumber(tag rpc.BlockNumber) hexutil.Uint {
+	return 7
+}
+
+func TestFetchSnapshot(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", new(testSnapshotService)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	snap, errs := fetchSnapshot(context.Background(), client)
+	if snap.GasPrice == nil || snap.GasPrice.String() != "1000000000" {
+		t.Errorf("unexpected gas price: %v", snap.GasPrice)
+	}
+	if snap.Block == nil || snap.Block.Number != 100 || snap.Pending != 7 || snap.Sync != nil {
+		t.Errorf("unexpected snapshot: %+v", snap)
+	}
+	// the net namespace and the finality tags are missing, only their fields fail
+	if len(errs) != 2 || errs["PeerCount"] == "" || errs["Finality"] == "" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
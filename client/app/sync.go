@@ -0,0 +1,49 @@
+package app
+
+import (
+	"encoding/json"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// rpcSyncProgress is the eth_syncing result while the node is syncing
+type rpcSyncProgress struct {
+	StartingBlock    hexutil.Uint64 `json:"startingBlock"`
+	CurrentBlock     hexutil.Uint64 `json:"currentBlock"`
+	HighestBlock     hexutil.Uint64 `json:"highestBlock"`
+	PulledStates     hexutil.Uint64 `json:"pulledStates"`
+	KnownStates      hexutil.Uint64 `json:"knownStates"`
+	SyncedAccounts   hexutil.Uint64 `json:"syncedAccounts"`
+	SyncedBytecodes  hexutil.Uint64 `json:"syncedBytecodes"`
+	SyncedStorage    hexutil.Uint64 `json:"syncedStorage"`
+	HealedTrienodes  hexutil.Uint64 `json:"healedTrienodes"`
+	HealedBytecodes  hexutil.Uint64 `json:"healedBytecodes"`
+	HealingTrienodes hexutil.Uint64 `json:"healingTrienodes"`
+	HealingBytecode  hexutil.Uint64 `json:"healingBytecode"`
+}
+
+// parseSyncProgress decodes the eth_syncing result, which is false once the node is in sync
+func parseSyncProgress(raw json.RawMessage) (*model.SyncProgress, error) {
+	var syncing bool
+	if err := json.Unmarshal(raw, &syncing); err == nil {
+		return nil, nil
+	}
+	var progress rpcSyncProgress
+	if err := json.Unmarshal(raw, &progress); err != nil {
+		return nil, err
+	}
+	return &model.SyncProgress{
+		StartingBlock:    uint64(progress.StartingBlock),
+		CurrentBlock:     uint64(progress.CurrentBlock),
+		HighestBlock:     uint64(progress.HighestBlock),
+		PulledStates:     uint64(progress.PulledStates),
+		KnownStates:      uint64(progress.KnownStates),
+		SyncedAccounts:   uint64(progress.SyncedAccounts),
+		SyncedBytecodes:  uint64(progress.SyncedBytecodes),
+		SyncedStorage:    uint64(progress.SyncedStorage),
+		HealedTrienodes:  uint64(progress.HealedTrienodes),
+		HealedBytecodes:  uint64(progress.HealedBytecodes),
+		HealingTrienodes: uint64(progress.HealingTrienodes),
+		HealingBytecode:  uint64(progress.HealingBytecode),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"ethstats/client/app/model"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultTxpoolTopSenders = 10
+)
+
+var txpoolGasPricePercentiles = []float64{0, 25, 50, 75, 100}
+
+// fetchTxpool requests the pending and queued counts by txpool_status, and the summary of
+// txpool_inspect if it's enabled. The inspect result lists every transaction, so it's opt-in
+func fetchTxpool(ctx context.Context, c caller, inspect bool, topSenders int) (*model.Txpool, error) {
+	var status map[string]hexutil.Uint64
+	if err := c.CallContext(ctx, &status, "txpool_status"); err != nil {
+		return nil, err
+	}
+	txpool := &model.Txpool{
+		Pending: uint64(status["pending"]),
+		Queued:  uint64(status["queued"]),
+	}
+	if !inspect {
+		return txpool, nil
+	}
+
+	var content map[string]map[string]map[string]string
+	if err := c.CallContext(ctx, &content, "txpool_inspect"); err != nil {
+		return txpool, err
+	}
+	txpool.Inspect = summarizeTxpool(content, topSenders)
+	return txpool, nil
+}
+
+// summarizeTxpool reduces the txpool_inspect content to the top senders and the gas price distribution
+func summarizeTxpool(content map[string]map[string]map[string]string, topSenders int) *model.TxpoolInspect {
+	if topSenders <= 0 {
+		topSenders = defaultTxpoolTopSenders
+	}
+	senders := make(map[string]*model.TxpoolSender)
+	var prices []*big.Int
+	for pool, accounts := range content {
+		for account, txs := range accounts {
+			sender := senders[account]
+			if sender == nil {
+				sender = &model.TxpoolSender{Address: account}
+				senders[account] = sender
+			}
+			switch pool {
+			case "pending":
+				sender.Pending += len(txs)
+			case "queued":
+				sender.Queued += len(txs)
+			}
+			for _, tx := range txs {
+				if price := parseInspectGasPrice(tx); price != nil {
+					prices = append(prices, price)
+				}
+			}
+		}
+	}
+
+	list := make([]model.TxpoolSender, 0, len(senders))
+	for _, sender := range senders {
+		list = append(list, *sender)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		ci, cj := list[i].Pending+list[i].Queued, list[j].Pending+list[j].Queued
+		if ci != cj {
+			return ci > cj
+		}
+		return list[i].Address < list[j].Address
+	})
+	if len(list) > topSenders {
+		list = list[:topSenders]
+	}
+
+	inspect := &model.TxpoolInspect{
+		TopSenders:          list,
+		GasPricePercentiles: txpoolGasPricePercentiles,
+	}
+	if len(prices) > 0 {
+		sort.Slice(prices, func(i, j int) bool {
+			return prices[i].Cmp(prices[j]) < 0
+		})
+		for _, p := range txpoolGasPricePercentiles {
+			index := int(p / 100 * float64(len(prices)-1))
+			inspect.GasPrice = append(inspect.GasPrice, (*math.Decimal256)(prices[index]))
+		}
+	}
+	return inspect
+}
+
+// parseInspectGasPrice parses the gas price of a txpool_inspect entry, which looks like
+// "0x...: 0 wei + 21000 gas × 1000000000 wei"
+func parseInspectGasPrice(tx string) *big.Int {
+	index := strings.LastIndex(tx, "×")
+	if index < 0 {
+		return nil
+	}
+	price := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(tx[index+len("×"):]), "wei"))
+	value, ok := new(big.Int).SetString(price, 10)
+	if !ok {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package app
+
+import "testing"
+
+func TestSummarizeTxpool(t *testing.T) {
+	content := map[string]map[string]map[string]string{
+		"pending": {
+			"0xaa": {
+				"0": "0x01: 0 wei + 21000 gas × 1000000000 wei",
+				"1": "0x01: 0 wei + 21000 gas × 3000000000 wei",
+			},
+			"0xbb": {"7": "contract creation: 0 wei + 500000 gas × 2000000000 wei"},
+		},
+		"queued": {
+			"0xaa": {"5": "0x01: 0 wei + 21000 gas × 5000000000 wei"},
+			"0xcc": {"2": "0x01: 0 wei + 21000 gas × invalid wei"},
+		},
+	}
+	inspect := summarizeTxpool(content, 2)
+	if len(inspect.TopSenders) != 2 {
+		t.Fatalf("top sender count mismatch: have %d, want 2", len(inspect.TopSenders))
+	}
+	if top := inspect.TopSenders[0]; top.Address != "0xaa" || top.Pending != 2 || top.Queued != 1 {
+		t.Errorf("unexpected top sender: %+v", top)
+	}
+	if len(inspect.GasPrice) != len(txpoolGasPricePercentiles) {
+		t.Fatalf("gas price percentile count mismatch: have %d", len(inspect.GasPrice))
+	}
+	if min, max := inspect.GasPrice[0].String(), inspect.GasPrice[len(inspect.GasPrice)-1].String(); min != "1000000000" || max != "5000000000" {
+		t.Errorf("gas price range mismatch: have %s~%s", min, max)
+	}
+}
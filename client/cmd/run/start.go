@@ -19,13 +19,24 @@ const (
 	version   = "version"
 	secret    = "secret"
 	serverUrl = "server-url"
+	labels    = "labels"
 	logPath   = "log-path"
 	logLevel  = "log-level"
 	logStdout = "log-stdout"
 	logType   = "log-type"
 	logCap    = "log-cap"
+	chainType = "chain-type"
 	chainUrl  = "chain-url"
+	rollupUrl = "rollup-url"
+	l1Url     = "l1-url"
 	chainPort = "chain-port"
+	feeBlocks = "fee-blocks"
+	txpool    = "txpool-inspect"
+	peers     = "peers"
+	chainId   = "chain-id"
+	networkId = "network-id"
+	genesis   = "genesis"
+	audit     = "audit-interval"
 )
 
 func init() {
@@ -55,6 +66,9 @@ func init() {
 			if serverUrl, _ := flag.GetString(serverUrl); serverUrl != "" && config.ApplicationConfig.ServerUrl == "" {
 				config.ApplicationConfig.ServerUrl = serverUrl
 			}
+			if labels, _ := flag.GetStringSlice(labels); len(labels) > 0 && len(config.ApplicationConfig.Labels) == 0 {
+				config.ApplicationConfig.Labels = labels
+			}
 			if logPath, _ := flag.GetString(logPath); logPath != "" && config.LoggerConfig.Path == "" {
 				config.LoggerConfig.Path = logPath
 			}
@@ -73,9 +87,39 @@ func init() {
 			if chainUrl, _ := flag.GetString(chainUrl); chainUrl != "" {
 				config.ChainConfig.Url = chainUrl
 			}
+			if chainType, _ := flag.GetString(chainType); chainType != "" && config.ChainConfig.Type == "" {
+				config.ChainConfig.Type = chainType
+			}
+			if rollupUrl, _ := flag.GetString(rollupUrl); rollupUrl != "" && config.ChainConfig.RollupUrl == "" {
+				config.ChainConfig.RollupUrl = rollupUrl
+			}
+			if l1Url, _ := flag.GetString(l1Url); l1Url != "" && config.ChainConfig.L1Url == "" {
+				config.ChainConfig.L1Url = l1Url
+			}
 			if chainPort, _ := flag.GetString(chainPort); chainPort != "" && config.ChainConfig.Port == "" {
 				config.ChainConfig.Port = chainPort
 			}
+			if feeBlocks, _ := flag.GetUint64(feeBlocks); feeBlocks > 0 && config.ChainConfig.FeeBlocks <= 0 {
+				config.ChainConfig.FeeBlocks = feeBlocks
+			}
+			if txpool, _ := flag.GetBool(txpool); txpool {
+				config.ChainConfig.TxpoolInspect = true
+			}
+			if peers, _ := flag.GetBool(peers); peers {
+				config.ChainConfig.Peers = true
+			}
+			if chainId, _ := flag.GetString(chainId); chainId != "" && config.ChainConfig.ChainId == "" {
+				config.ChainConfig.ChainId = chainId
+			}
+			if networkId, _ := flag.GetString(networkId); networkId != "" && config.ChainConfig.NetworkId == "" {
+				config.ChainConfig.NetworkId = networkId
+			}
+			if genesis, _ := flag.GetString(genesis); genesis != "" && config.ChainConfig.Genesis == "" {
+				config.ChainConfig.Genesis = genesis
+			}
+			if audit, _ := flag.GetInt64(audit); audit > 0 && config.AuditConfig.Interval <= 0 {
+				config.AuditConfig.Interval = audit
+			}
 			if config.ApplicationConfig.Name == "" {
 				log.Fatal("param name can't empty")
 			}
@@ -85,7 +129,7 @@ func init() {
 			if config.ApplicationConfig.ServerUrl == "" {
 				log.Fatal("param serverUrl can't empty")
 			}
-			if config.ChainConfig.Url == "" {
+			if config.ChainConfig.Url == "" && len(config.ChainConfig.Endpoints) == 0 {
 				log.Fatal("param chainUrl can't empty")
 			}
 		},
@@ -101,13 +145,24 @@ func init() {
 	cmd.String(version, "v1.0.0", "version")
 	cmd.String(secret, "", "secret")
 	cmd.String(serverUrl, "", "server url")
+	cmd.StringSlice(labels, nil, "node labels, comma separated")
 	cmd.String(logPath, "", "log path")
 	cmd.String(logLevel, "trace", "log level")
 	cmd.String(logStdout, "default", "default,file")
 	cmd.String(logType, "default", "default、zap、logrus")
 	cmd.Uint(logCap, 50, "log cap")
+	cmd.String(chainType, "", "chain type: ethereum, optimism, arbitrum")
 	cmd.String(chainUrl, "", "chain url with port,eg:https://127.0.0.1:30303")
+	cmd.String(rollupUrl, "", "optimism rollup node url")
+	cmd.String(l1Url, "", "arbitrum l1 url")
 	cmd.String(chainPort, "30303", "chain port,use for report")
+	cmd.Uint64(feeBlocks, 20, "block count of the fee history")
+	cmd.Bool(txpool, false, "report the txpool_inspect summary")
+	cmd.Bool(peers, false, "report the admin_peers list")
+	cmd.String(chainId, "", "expected chain id")
+	cmd.String(networkId, "", "expected network id")
+	cmd.String(genesis, "", "expected genesis hash")
+	cmd.Int64(audit, 0, "rpc security audit interval in second, 0 disables the audit")
 }
 
 func run() error {
@@ -6,6 +6,7 @@ type Application struct {
 	Version   string
 	Secret    string
 	ServerUrl string
+	Labels    []string //节点标签，前端可按标签订阅
 }
 
 var ApplicationConfig = new(Application)
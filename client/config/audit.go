@@ -0,0 +1,9 @@
+package config
+
+type Audit struct {
+	Interval   int64
+	RpcPort    string
+	EnginePort string
+}
+
+var AuditConfig = new(Audit)
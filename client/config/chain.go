@@ -1,9 +1,31 @@
 package config
 
 type Chain struct {
-	Url     string
-	Timeout int64
-	Port    string
+	Type           string
+	Url            string
+	Endpoints      []Endpoint //配置后优先于Url
+	RollupUrl      string
+	L1Url          string
+	Timeout        int64
+	Port           string
+	FeeBlocks      uint64
+	FeePercentiles []float64
+	TxpoolInspect  bool
+	TxpoolSenders  int
+	Peers          bool
+	ChainId        string
+	NetworkId      string
+	Genesis        string
+}
+
+// Endpoint is a node rpc endpoint, the url is a http/ws url or an ipc socket path
+type Endpoint struct {
+	Url       string
+	Priority  int    //越小越优先，相同时按配置顺序
+	JwtSecret string //jwt secret文件路径，节点rpc开启jwt校验时使用
+	Username  string //basic auth
+	Password  string
+	Headers   map[string]string //网关所需的自定义header
 }
 
 var ChainConfig = new(Chain)
@@ -11,6 +11,7 @@ type Config struct {
 	Application *Application `yaml:"application"`
 	Logger      *Logger      `yaml:"logger"`
 	Chain       *Chain       `yaml:"chain"`
+	Audit       *Audit       `yaml:"audit"`
 	callbacks   []func()
 }
 
@@ -40,6 +41,7 @@ func Setup(s source.Source,
 	_cfg := &Config{
 		Application: ApplicationConfig,
 		Chain:       ChainConfig,
+		Audit:       AuditConfig,
 		Logger:      LoggerConfig,
 		callbacks:   fs,
 	}
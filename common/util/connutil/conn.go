@@ -58,6 +58,14 @@ func (w *ConnWrapper) WriteMessage(messageType int, data []byte) error {
 	return w.conn.WriteMessage(messageType, data)
 }
 
+// SetWriteDeadline bounds the next writes, a zero time removes the bound
+func (w *ConnWrapper) SetWriteDeadline(t time.Time) error {
+	w.wlock.Lock()
+	defer w.wlock.Unlock()
+
+	return w.conn.SetWriteDeadline(t)
+}
+
 func (w *ConnWrapper) ReadMessage() (messageType int, p []byte, err error) {
 	w.rlock.Lock()
 	defer w.rlock.Unlock()
@@ -69,6 +77,36 @@ func (w *ConnWrapper) RemoteAddr() net.Addr {
 	return w.conn.RemoteAddr()
 }
 
+// StartKeepalive sends a ping frame every interval and requires a pong within timeout, so a
+// read on a silently dead connection fails instead of blocking forever. The returned function
+// stops the pings
+func (w *ConnWrapper) StartKeepalive(interval, timeout time.Duration) func() {
+	_ = w.conn.SetReadDeadline(time.Now().Add(timeout))
+	w.conn.SetPongHandler(func(string) error {
+		return w.conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// WriteControl can be called concurrently with the other methods
+				if err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+	}
+}
+
 // Close wraps corresponding method on the websocket but is safe for concurrent calling
 func (w *ConnWrapper) Close() error {
 	// The Close and WriteControl methods can be called concurrently with all other methods,
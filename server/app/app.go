@@ -6,6 +6,7 @@ import (
 	"ethstats/server/config"
 	"github.com/bitxx/logger"
 	"github.com/bitxx/logger/logbase"
+	"net"
 	"net/http"
 )
 
@@ -16,10 +17,21 @@ type App struct {
 
 func NewApp() *App {
 	channel := &model.Channel{
-		MsgPing:    make(chan []byte),
-		MsgLatency: make(chan []byte),
+		MsgPing:    make(chan []byte, 256),
+		MsgLatency: make(chan []byte, 256),
+		MsgAlert:   make(chan []byte, 64),
+		MsgEvent:   make(chan []byte, 64),
 		Nodes:      make(map[string][]byte),
 		LoginIDs:   make(map[string]string),
+		Reports:    model.NewReports(),
+		Chains:     model.NewChains(),
+		Fees:       model.NewFees(),
+		Rollups:    model.NewRollups(),
+		Finalities: model.NewFinalities(),
+		Syncs:      model.NewSyncs(),
+		Latencies:  model.NewLatencies(),
+		Liveness:   model.NewLiveness(),
+		History:    model.NewHistory(),
 	}
 	return &App{
 		channel: channel,
@@ -34,9 +46,27 @@ func NewApp() *App {
 }
 
 func (a *App) Start() {
-	relay := service.NewRelay(a.channel, a.logger)
+	alerter := service.NewAlerter(a.channel, a.logger)
+	relay := service.NewRelay(a.channel, alerter, a.logger)
 	api := service.NewApi(a.channel, a.logger)
 	http.HandleFunc("/", relay.HandleRequest)
-	http.HandleFunc("/api", api.HandleRequest)
+	http.HandleFunc("/api", service.Protect(service.RoleViewer, api.HandleRequest))
+	http.HandleFunc("/api/events", service.Protect(service.RoleViewer, api.HandleEvents))
+	if config.ApiConfig.SocketIo {
+		http.HandleFunc("/socket.io/", service.ProtectHandshake(api.HandleSocketIo))
+	}
+	if config.ApiConfig.Dashboard {
+		http.HandleFunc("/primus", service.Protect(service.RoleViewer, api.HandleDashboard))
+		http.HandleFunc("/primus/", service.Protect(service.RoleViewer, api.HandleDashboard))
+	}
+	if config.ApiConfig.GrpcPort != "" {
+		lis, err := net.Listen("tcp", config.ApplicationConfig.Host+":"+config.ApiConfig.GrpcPort)
+		if err != nil {
+			a.logger.Fatal(err)
+		}
+		go func() {
+			a.logger.Error(api.NewGrpcServer().Serve(lis))
+		}()
+	}
 	a.logger.Fatal(http.ListenAndServe(config.ApplicationConfig.Host+":"+config.ApplicationConfig.Port, nil))
 }
@@ -0,0 +1,16 @@
+package model
+
+const (
+	AlertCritical = "critical"
+	AlertWarning  = "warning"
+	AlertInfo     = "info"
+)
+
+// Alert is an abnormal state of a node, sent by email and to the hub clients
+type Alert struct {
+	Id      string // node id
+	Type    string // kind of the alert, the same kind of a node is rate limited
+	Level   string // critical, warning or info
+	Message string
+	Time    int64 // unix second
+}
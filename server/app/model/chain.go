@@ -0,0 +1,124 @@
+package model
+
+import (
+	"sort"
+	"sync"
+)
+
+// chainHistorySize is the number of recent blocks kept per chain to derive the chain stats
+const chainHistorySize = 128
+
+// ChainStats contains the chain level stats derived from the blocks reported by all nodes
+type ChainStats struct {
+	ChainId        string
+	BestBlock      uint64
+	BlockCount     int     // number of recent blocks the stats are derived from
+	GasUtilization float64 // average gasUsed/gasLimit, 0~1
+	AvgBlockTime   float64 // seconds
+	TPS            float64
+}
+
+// Chains collects the latest blocks reported by the nodes, grouped by chain id.
+// Nodes report in intervals, so not every block is seen, the stats are averaged over the seen ones
+type Chains struct {
+	lock   sync.RWMutex
+	blocks map[string]map[uint64]*Block
+}
+
+// NewChains creates an empty block collector
+func NewChains() *Chains {
+	return &Chains{blocks: make(map[string]map[uint64]*Block)}
+}
+
+// Add records the block reported for the chain, a block with the same number replaces the
+// previous one, so reorgs are followed
+func (c *Chains) Add(chainId string, block *Block) {
+	if block == nil || block.Number == 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	blocks := c.blocks[chainId]
+	if blocks == nil {
+		blocks = make(map[uint64]*Block)
+		c.blocks[chainId] = blocks
+	}
+	blocks[block.Number] = block
+
+	// drop the oldest blocks
+	for len(blocks) > chainHistorySize {
+		oldest := block.Number
+		for number := range blocks {
+			if number < oldest {
+				oldest = number
+			}
+		}
+		delete(blocks, oldest)
+	}
+}
+
+// Stats returns the stats of all known chains
+func (c *Chains) Stats() []ChainStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	stats := make([]ChainStats, 0, len(c.blocks))
+	for chainId, blocks := range c.blocks {
+		list := make([]*Block, 0, len(blocks))
+		for _, block := range blocks {
+			list = append(list, block)
+		}
+		stat := calcChainStats(list)
+		stat.ChainId = chainId
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ChainId < stats[j].ChainId
+	})
+	return stats
+}
+
+// Blocks returns the recent blocks of the chain, oldest first
+func (c *Chains) Blocks(chainId string) []*Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	blocks := make([]*Block, 0, len(c.blocks[chainId]))
+	for _, block := range c.blocks[chainId] {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Number < blocks[j].Number
+	})
+	return blocks
+}
+
+// calcChainStats derives the chain stats from the given blocks
+func calcChainStats(blocks []*Block) ChainStats {
+	stats := ChainStats{BlockCount: len(blocks)}
+	if len(blocks) == 0 {
+		return stats
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Number < blocks[j].Number
+	})
+	first, last := blocks[0], blocks[len(blocks)-1]
+	stats.BestBlock = last.Number
+
+	utilization, txCount := 0.0, 0
+	for _, block := range blocks {
+		if block.GasLimit > 0 {
+			utilization += float64(block.GasUsed) / float64(block.GasLimit)
+		}
+		txCount += block.TxCount
+	}
+	stats.GasUtilization = utilization / float64(len(blocks))
+
+	if last.Number > first.Number && last.Time > first.Time {
+		stats.AvgBlockTime = float64(last.Time-first.Time) / float64(last.Number-first.Number)
+		// only the seen blocks carry a tx count, so use their average per block
+		stats.TPS = float64(txCount) / float64(len(blocks)) / stats.AvgBlockTime
+	}
+	return stats
+}
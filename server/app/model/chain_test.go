@@ -0,0 +1,46 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChainStats(t *testing.T) {
+	chains := NewChains()
+	chains.Add("1", &Block{Number: 100, Time: 1200, GasUsed: 15000000, GasLimit: 30000000, TxCount: 120})
+	chains.Add("1", &Block{Number: 110, Time: 1320, GasUsed: 30000000, GasLimit: 30000000, TxCount: 240})
+	chains.Add("5", &Block{Number: 7, Time: 84, GasUsed: 0, GasLimit: 30000000})
+	chains.Add("1", nil)
+
+	stats := chains.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("chain count mismatch: have %d, want 2", len(stats))
+	}
+	mainnet := stats[0]
+	if mainnet.ChainId != "1" || mainnet.BestBlock != 110 || mainnet.BlockCount != 2 {
+		t.Fatalf("unexpected chain stats: %+v", mainnet)
+	}
+	if mainnet.AvgBlockTime != 12 {
+		t.Errorf("avg block time mismatch: have %v, want 12", mainnet.AvgBlockTime)
+	}
+	if mainnet.GasUtilization != 0.75 {
+		t.Errorf("gas utilization mismatch: have %v, want 0.75", mainnet.GasUtilization)
+	}
+	if math.Abs(mainnet.TPS-15) > 1e-9 {
+		t.Errorf("tps mismatch: have %v, want 15", mainnet.TPS)
+	}
+	if stats[1].AvgBlockTime != 0 || stats[1].TPS != 0 {
+		t.Errorf("single block must not derive block time: %+v", stats[1])
+	}
+}
+
+func TestChainStatsHistoryLimit(t *testing.T) {
+	chains := NewChains()
+	for i := uint64(1); i <= chainHistorySize+10; i++ {
+		chains.Add("1", &Block{Number: i, Time: i * 12, GasLimit: 30000000})
+	}
+	stats := chains.Stats()
+	if stats[0].BlockCount != chainHistorySize {
+		t.Fatalf("history size mismatch: have %d, want %d", stats[0].BlockCount, chainHistorySize)
+	}
+}
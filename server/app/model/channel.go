@@ -7,9 +7,42 @@ type Channel struct {
 	MsgPing    chan []byte
 	MsgLatency chan []byte
 
+	// MsgAlert is the encoded alerts to send to the hub clients
+	MsgAlert chan []byte
+
+	// MsgEvent is the encoded node lifecycle events to send to the hub clients
+	MsgEvent chan []byte
+
 	// Nodes registered to the relay server
 	Nodes map[string][]byte
 
 	//use for flag the login client
 	LoginIDs map[string]string
+
+	// Reports keeps the latest parsed stats of every node
+	Reports *Reports
+
+	// Chains collects the reported blocks to derive the chain level stats
+	Chains *Chains
+
+	// Rollups tracks the safe head of the L2 nodes
+	Rollups *Rollups
+
+	// Finalities tracks the finalized height of every chain
+	Finalities *Finalities
+
+	// Syncs tracks the sync progress of the syncing nodes
+	Syncs *Syncs
+
+	// Liveness tracks when the connected nodes last sent stats
+	Liveness *Liveness
+
+	// Latencies tracks the latency history of the nodes
+	Latencies *Latencies
+
+	// History keeps the recent stats points of every node
+	History *History
+
+	// Fees collects the reported fee history to expose the fee trends
+	Fees *Fees
 }
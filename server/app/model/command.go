@@ -0,0 +1,55 @@
+package model
+
+import "encoding/json"
+
+// ApiRequest is a command sent by a hub client, as emit [command, request]
+type ApiRequest struct {
+	RequestId string   `json:"requestId"` // echoed in the response
+	Id        string   `json:"id"`        // node id of get-node and get-history
+	From      int64    `json:"from"`      // unix second of get-history, 0 for the oldest point
+	To        int64    `json:"to"`        // unix second of get-history, 0 for the latest point
+	Nodes     []string `json:"nodes"`     // node ids of subscribe and unsubscribe
+	Labels    []string `json:"labels"`    // node labels of subscribe and unsubscribe
+	Events    []string `json:"events"`    // emit names of subscribe and unsubscribe
+}
+
+// ApiResponse answers a hub client command, Error is empty on success
+type ApiResponse struct {
+	RequestId string
+	Command   string
+	Error     string
+	Data      interface{}
+}
+
+// NodeDetail is the state of a node answered to get-node
+type NodeDetail struct {
+	Id      string
+	Stats   *Stats
+	Latency *LatencyStats
+	Sync    *SyncStats
+}
+
+// Subscription restricts the feed of a hub client. An empty set doesn't restrict, so a
+// client without subscription gets the whole feed
+type Subscription struct {
+	Nodes  []string
+	Labels []string
+	Events []string
+}
+
+// ApiClientInfo describes a connected hub client, answered to get-clients
+type ApiClientInfo struct {
+	Addr         string
+	Role         string
+	Subscription Subscription
+	Queued       int // messages waiting in the send queue
+	Dropped      int // messages dropped because the client was too slow
+}
+
+// StatePatch carries the changed fields of a state entry as a JSON merge patch, to apply to the
+// value of the emit of the node (or of the fleet if Id is empty) received before
+type StatePatch struct {
+	Emit  string
+	Id    string
+	Patch json.RawMessage
+}
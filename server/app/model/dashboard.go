@@ -0,0 +1,256 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"sort"
+	"time"
+)
+
+// dashboardChartSize is the number of recent blocks drawn in the charts of the classic dashboard
+const dashboardChartSize = 40
+
+// DashboardNode is a node in the schema of the classic ethstats dashboard
+type DashboardNode struct {
+	Id      string         `json:"id"`
+	Info    DashboardInfo  `json:"info"`
+	Stats   DashboardStats `json:"stats"`
+	History []int64        `json:"history"`
+	Geo     *struct{}      `json:"geo"`
+}
+
+// DashboardInfo is the identity of a node in the classic dashboard
+type DashboardInfo struct {
+	Name             string `json:"name"`
+	Node             string `json:"node"`
+	Port             string `json:"port"`
+	Net              string `json:"net"`
+	Protocol         string `json:"protocol"`
+	Api              string `json:"api"`
+	Os               string `json:"os"`
+	OsV              string `json:"os_v"`
+	Client           string `json:"client"`
+	CanUpdateHistory bool   `json:"canUpdateHistory"`
+}
+
+// DashboardStats is the state of a node in the classic dashboard, the block and pending are
+// only sent with the whole node
+type DashboardStats struct {
+	Active         bool             `json:"active"`
+	Mining         bool             `json:"mining"`
+	Syncing        bool             `json:"syncing"`
+	Hashrate       int64            `json:"hashrate"`
+	Peers          uint64           `json:"peers"`
+	GasPrice       *math.Decimal256 `json:"gasPrice"`
+	Uptime         int              `json:"uptime"`
+	Latency        int64            `json:"latency"`
+	Block          *DashboardBlock  `json:"block,omitempty"`
+	Pending        *uint            `json:"pending,omitempty"`
+	PropagationAvg *int64           `json:"propagationAvg,omitempty"`
+}
+
+// DashboardBlock is the latest block of a node in the classic dashboard. Only the counts of
+// the transactions and uncles are known, so their lists hold empty hashes
+type DashboardBlock struct {
+	Number          uint64           `json:"number"`
+	Hash            string           `json:"hash"`
+	ParentHash      string           `json:"parentHash"`
+	Timestamp       uint64           `json:"timestamp"`
+	Miner           string           `json:"miner"`
+	GasUsed         uint64           `json:"gasUsed"`
+	GasLimit        uint64           `json:"gasLimit"`
+	Difficulty      *math.Decimal256 `json:"difficulty"`
+	TotalDifficulty *math.Decimal256 `json:"totalDifficulty"` // not reported by the nodes, left null
+	Transactions    []string         `json:"transactions"`
+	Uncles          []string         `json:"uncles"`
+	Arrived         int64            `json:"arrived"`
+	Received        int64            `json:"received"`
+	Propagation     int64            `json:"propagation"`
+}
+
+// DashboardBlockStats is the block message of the classic dashboard
+type DashboardBlockStats struct {
+	Id             string          `json:"id"`
+	Block          *DashboardBlock `json:"block"`
+	PropagationAvg int64           `json:"propagationAvg"`
+	History        []int64         `json:"history"`
+}
+
+// DashboardCharts is the charts message of the classic dashboard
+type DashboardCharts struct {
+	Height       []uint64             `json:"height"`
+	Blocktime    []float64            `json:"blocktime"`
+	AvgBlocktime float64              `json:"avgBlocktime"`
+	Difficulty   []*math.Decimal256   `json:"difficulty"`
+	Uncles       []int                `json:"uncles"`
+	Transactions []int                `json:"transactions"`
+	GasSpending  []uint64             `json:"gasSpending"`
+	GasLimit     []uint64             `json:"gasLimit"`
+	Miners       []DashboardMiner     `json:"miners"`
+	Propagation  DashboardPropagation `json:"propagation"`
+	UncleCount   []int                `json:"uncleCount"`
+	AvgHashrate  int64                `json:"avgHashrate"`
+}
+
+// DashboardMiner is the number of recent blocks of a miner
+type DashboardMiner struct {
+	Miner  string `json:"miner"`
+	Name   bool   `json:"name"`
+	Blocks int    `json:"blocks"`
+}
+
+// DashboardPropagation is the block propagation histogram, the reports don't tell it
+type DashboardPropagation struct {
+	Histogram []struct{} `json:"histogram"`
+	Avg       int64      `json:"avg"`
+}
+
+// NewDashboardNode converts the latest report of the node, latency may be nil
+func NewDashboardNode(id string, stats *Stats, latency *LatencyStats) DashboardNode {
+	node := DashboardNode{
+		Id: id,
+		Info: DashboardInfo{
+			Name:     stats.NodeInfo.Name,
+			Node:     stats.NodeInfo.Node,
+			Port:     stats.NodeInfo.ChainPort,
+			Net:      stats.NodeInfo.Net,
+			Protocol: stats.NodeInfo.Protocol,
+			Api:      stats.NodeInfo.Api,
+			Os:       stats.NodeInfo.OS,
+			Client:   stats.NodeInfo.Client,
+		},
+		Stats:   NewDashboardStats(stats, latency),
+		History: newDashboardHistory(),
+	}
+	if node.Info.Name == "" {
+		node.Info.Name = id
+	}
+	var propagation int64
+	pending := stats.Pending
+	node.Stats.Block = newDashboardBlock(stats.Block)
+	node.Stats.Pending = &pending
+	node.Stats.PropagationAvg = &propagation
+	return node
+}
+
+// NewDashboardBlockStats converts the latest block of the report
+func NewDashboardBlockStats(id string, stats *Stats) DashboardBlockStats {
+	return DashboardBlockStats{
+		Id:      id,
+		Block:   newDashboardBlock(stats.Block),
+		History: newDashboardHistory(),
+	}
+}
+
+// newDashboardHistory is the block propagation history, the reports don't tell it
+func newDashboardHistory() []int64 {
+	history := make([]int64, dashboardChartSize)
+	for i := range history {
+		history[i] = -1
+	}
+	return history
+}
+
+// NewDashboardStats converts the basic stats of the report, latency may be nil
+func NewDashboardStats(stats *Stats, latency *LatencyStats) DashboardStats {
+	s := DashboardStats{
+		Active:   stats.Active,
+		Syncing:  stats.Syncing,
+		Peers:    stats.PeerCount,
+		GasPrice: stats.GasPrice,
+	}
+	if stats.Active {
+		s.Uptime = 100
+	}
+	if latency != nil {
+		s.Latency = int64(latency.Quality.Avg)
+	}
+	return s
+}
+
+func newDashboardBlock(block *Block) *DashboardBlock {
+	if block == nil {
+		return &DashboardBlock{Transactions: []string{}, Uncles: []string{}}
+	}
+	now := time.Now().UnixMilli()
+	return &DashboardBlock{
+		Number:       block.Number,
+		Hash:         block.Hash,
+		ParentHash:   block.ParentHash,
+		Timestamp:    block.Time,
+		Miner:        block.Miner,
+		GasUsed:      block.GasUsed,
+		GasLimit:     block.GasLimit,
+		Difficulty:   block.Difficulty,
+		Transactions: make([]string, block.TxCount),
+		Uncles:       make([]string, block.UncleCount),
+		Arrived:      now,
+		Received:     now,
+	}
+}
+
+// CalcDashboardCharts draws the charts from the recent blocks of the chain, oldest first
+func CalcDashboardCharts(blocks []*Block) DashboardCharts {
+	if len(blocks) > dashboardChartSize {
+		blocks = blocks[len(blocks)-dashboardChartSize:]
+	}
+	charts := DashboardCharts{
+		Height:       []uint64{},
+		Blocktime:    []float64{},
+		Difficulty:   []*math.Decimal256{},
+		Uncles:       []int{},
+		Transactions: []int{},
+		GasSpending:  []uint64{},
+		GasLimit:     []uint64{},
+		Miners:       []DashboardMiner{},
+		UncleCount:   []int{},
+		Propagation:  DashboardPropagation{Histogram: []struct{}{}},
+	}
+	miners := make(map[string]int)
+	for i, block := range blocks {
+		blocktime := 0.0
+		// the blocks not seen by any node leave a gap, spread it over the missing blocks
+		if i > 0 && block.Number > blocks[i-1].Number && block.Time >= blocks[i-1].Time {
+			blocktime = float64(block.Time-blocks[i-1].Time) / float64(block.Number-blocks[i-1].Number)
+		}
+		charts.Height = append(charts.Height, block.Number)
+		charts.Blocktime = append(charts.Blocktime, blocktime)
+		charts.Difficulty = append(charts.Difficulty, block.Difficulty)
+		charts.Uncles = append(charts.Uncles, block.UncleCount)
+		charts.UncleCount = append(charts.UncleCount, block.UncleCount)
+		charts.Transactions = append(charts.Transactions, block.TxCount)
+		charts.GasSpending = append(charts.GasSpending, block.GasUsed)
+		charts.GasLimit = append(charts.GasLimit, block.GasLimit)
+		miners[block.Miner]++
+	}
+	if n := len(blocks); n > 1 && blocks[n-1].Number > blocks[0].Number {
+		charts.AvgBlocktime = float64(blocks[n-1].Time-blocks[0].Time) / float64(blocks[n-1].Number-blocks[0].Number)
+	}
+	for miner, count := range miners {
+		charts.Miners = append(charts.Miners, DashboardMiner{Miner: miner, Blocks: count})
+	}
+	sort.Slice(charts.Miners, func(i, j int) bool {
+		if charts.Miners[i].Blocks != charts.Miners[j].Blocks {
+			return charts.Miners[i].Blocks > charts.Miners[j].Blocks
+		}
+		return charts.Miners[i].Miner < charts.Miners[j].Miner
+	})
+	return charts
+}
+
+// DashboardNodeStats is the stats and inactive message of the classic dashboard
+type DashboardNodeStats struct {
+	Id    string         `json:"id"`
+	Stats DashboardStats `json:"stats"`
+}
+
+// DashboardPending is the pending message of the classic dashboard
+type DashboardPending struct {
+	Id      string `json:"id"`
+	Pending uint   `json:"pending"`
+}
+
+// DashboardLatency is the latency message of the classic dashboard
+type DashboardLatency struct {
+	Id      string `json:"id"`
+	Latency int64  `json:"latency"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common/math"
+	"strings"
+	"testing"
+)
+
+func TestCalcDashboardCharts(t *testing.T) {
+	blocks := []*Block{
+		{Number: 10, Time: 100, TxCount: 3, GasUsed: 10, GasLimit: 30, Miner: "a"},
+		{Number: 11, Time: 112, TxCount: 5, GasUsed: 20, GasLimit: 30, Miner: "b"},
+		{Number: 14, Time: 148, TxCount: 1, GasUsed: 5, GasLimit: 30, Miner: "b"},
+	}
+	charts := CalcDashboardCharts(blocks)
+	if len(charts.Height) != 3 || charts.Blocktime[1] != 12 || charts.Blocktime[2] != 12 || charts.AvgBlocktime != 12 {
+		t.Fatalf("unexpected block times: %+v", charts)
+	}
+	if len(charts.Miners) != 2 || charts.Miners[0].Miner != "b" || charts.Miners[0].Blocks != 2 {
+		t.Fatalf("unexpected miners: %+v", charts.Miners)
+	}
+	if empty := CalcDashboardCharts(nil); empty.Height == nil || empty.Miners == nil {
+		t.Fatal("empty charts should encode empty lists")
+	}
+}
+
+func TestNewDashboardNode(t *testing.T) {
+	stats := &Stats{
+		Active:    true,
+		PeerCount: 7,
+		Pending:   3,
+		NodeInfo:  Node{Name: "node-1", Net: "1", OS: "linux", OSPlatform: "amd64"},
+		Block:     &Block{Number: 42, TxCount: 2, Difficulty: math.NewDecimal256(2)},
+	}
+	node := NewDashboardNode("n1", stats, &LatencyStats{Quality: LatencyQuality{Avg: 12.6}})
+	content, _ := json.Marshal(node)
+	for _, field := range []string{`"id":"n1"`, `"name":"node-1"`, `"os":"linux"`, `"peers":7`, `"pending":3`,
+		`"os_v":""`, `"latency":12`, `"uptime":100`, `"number":42`, `"difficulty":"2"`, `"totalDifficulty":null`,
+		`"transactions":["",""]`} {
+		if !strings.Contains(string(content), field) {
+			t.Errorf("%s missing in %s", field, content)
+		}
+	}
+	if len(node.History) != dashboardChartSize || node.History[0] != -1 {
+		t.Errorf("unexpected history %v", node.History)
+	}
+}
@@ -0,0 +1,10 @@
+package model
+
+// NodeEvent is a lifecycle change of a node connection, broadcast to the hub clients
+type NodeEvent struct {
+	Id     string // node id, empty before the node authenticated
+	Addr   string // remote address of the connection
+	Code   int    // error code of node-offline, 0 if the connection closed without error
+	Reason string
+	Time   int64 // unix second
+}
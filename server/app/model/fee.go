@@ -0,0 +1,124 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// feeHistorySize is the number of fee points kept per chain
+	feeHistorySize = 360
+	// feeSpikeRatio marks a spike when the latest base fee reaches this multiple of the trend median
+	feeSpikeRatio = 2
+)
+
+// FeePoint is the fee market state after a block
+type FeePoint struct {
+	Block        uint64
+	Time         int64              // unix second the report was received
+	BaseFee      *math.Decimal256   // base fee of the next block
+	PriorityFee  []*math.Decimal256 // matches the Percentiles of the trend
+	GasUsedRatio float64            // average over the blocks of the report
+	BlobBaseFee  *math.Decimal256
+}
+
+// FeeTrend is the fee market history of a chain
+type FeeTrend struct {
+	ChainId     string
+	Percentiles []float64
+	Spike       bool
+	Points      []FeePoint
+}
+
+// Fees collects the fee reports of the nodes, grouped by chain id
+type Fees struct {
+	lock   sync.RWMutex
+	trends map[string]*FeeTrend
+}
+
+// NewFees creates an empty fee collector
+func NewFees() *Fees {
+	return &Fees{trends: make(map[string]*FeeTrend)}
+}
+
+// Add records the fee report of a node, reports not newer than the latest point are
+// ignored, because all nodes of a chain report the same blocks
+func (f *Fees) Add(chainId string, fee *Fee) {
+	if fee == nil || fee.LatestBlock == 0 {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	trend := f.trends[chainId]
+	if trend == nil {
+		trend = &FeeTrend{ChainId: chainId}
+		f.trends[chainId] = trend
+	}
+	if len(trend.Points) > 0 && trend.Points[len(trend.Points)-1].Block >= fee.LatestBlock {
+		return
+	}
+
+	point := FeePoint{
+		Block:       fee.LatestBlock,
+		Time:        time.Now().Unix(),
+		BaseFee:     fee.NextBaseFee,
+		PriorityFee: fee.PriorityFee,
+		BlobBaseFee: fee.BlobBaseFee,
+	}
+	if point.BaseFee == nil && len(fee.BaseFee) > 0 {
+		point.BaseFee = fee.BaseFee[len(fee.BaseFee)-1]
+	}
+	if len(fee.GasUsedRatio) > 0 {
+		for _, ratio := range fee.GasUsedRatio {
+			point.GasUsedRatio += ratio
+		}
+		point.GasUsedRatio /= float64(len(fee.GasUsedRatio))
+	}
+	trend.Percentiles = fee.Percentiles
+	trend.Points = append(trend.Points, point)
+	if len(trend.Points) > feeHistorySize {
+		trend.Points = trend.Points[len(trend.Points)-feeHistorySize:]
+	}
+	trend.Spike = isFeeSpike(trend.Points)
+}
+
+// Trends returns a copy of the fee trends of all known chains
+func (f *Fees) Trends() []FeeTrend {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	trends := make([]FeeTrend, 0, len(f.trends))
+	for _, trend := range f.trends {
+		t := *trend
+		t.Points = append([]FeePoint(nil), trend.Points...)
+		trends = append(trends, t)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].ChainId < trends[j].ChainId
+	})
+	return trends
+}
+
+// isFeeSpike checks whether the latest base fee jumped far above the median of the trend
+func isFeeSpike(points []FeePoint) bool {
+	var fees []*big.Int
+	for _, point := range points {
+		if point.BaseFee != nil {
+			fees = append(fees, (*big.Int)(point.BaseFee))
+		}
+	}
+	if len(fees) < 2 {
+		return false
+	}
+	latest := fees[len(fees)-1]
+	sorted := append([]*big.Int(nil), fees...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	median := sorted[len(sorted)/2]
+	return latest.Cmp(new(big.Int).Mul(median, big.NewInt(feeSpikeRatio))) >= 0
+}
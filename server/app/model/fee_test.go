@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"testing"
+)
+
+func TestFeeTrendSpike(t *testing.T) {
+	fees := NewFees()
+	for i, baseFee := range []int64{10, 11, 10, 12, 10} {
+		fees.Add("1", &Fee{LatestBlock: uint64(100 + i), NextBaseFee: math.NewDecimal256(baseFee)})
+	}
+	// stale report from a lagging node
+	fees.Add("1", &Fee{LatestBlock: 101, NextBaseFee: math.NewDecimal256(100)})
+	trend := fees.Trends()[0]
+	if len(trend.Points) != 5 || trend.Spike {
+		t.Fatalf("unexpected trend: points %d, spike %v", len(trend.Points), trend.Spike)
+	}
+
+	fees.Add("1", &Fee{LatestBlock: 105, NextBaseFee: math.NewDecimal256(25), GasUsedRatio: []float64{1, 0.5}})
+	trend = fees.Trends()[0]
+	if !trend.Spike {
+		t.Fatal("base fee jump not marked as spike")
+	}
+	if ratio := trend.Points[len(trend.Points)-1].GasUsedRatio; ratio != 0.75 {
+		t.Errorf("gas used ratio mismatch: have %v, want 0.75", ratio)
+	}
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NodeFinality is the finality lag of a node
+type NodeFinality struct {
+	Id           string
+	Latest       uint64
+	Safe         uint64
+	Finalized    uint64
+	FinalizedLag uint64 // Latest-Finalized, blocks
+}
+
+// ChainFinality is the fleet finality of a chain, from the best heads of its nodes
+type ChainFinality struct {
+	ChainId        string
+	Latest         uint64
+	Safe           uint64
+	Finalized      uint64
+	FinalizedLag   uint64
+	FinalizedSince int64 // unix second the finalized height last advanced
+	Stalled        bool
+	Nodes          []NodeFinality
+}
+
+type finalityState struct {
+	finalized uint64
+	since     int64
+}
+
+// Finalities tracks the best finalized height of every chain to find stalled finality
+type Finalities struct {
+	lock   sync.RWMutex
+	chains map[string]*finalityState
+}
+
+// NewFinalities creates an empty finality tracker
+func NewFinalities() *Finalities {
+	return &Finalities{chains: make(map[string]*finalityState)}
+}
+
+// Update records the finalized height reported for the chain, and returns whether the
+// finalized height didn't advance within stallTime seconds, 0 disables the check
+func (f *Finalities) Update(chainId string, finalized uint64, stallTime int64) (since int64, stalled bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now().Unix()
+	state := f.chains[chainId]
+	if state == nil || finalized > state.finalized {
+		state = &finalityState{finalized: finalized, since: now}
+		f.chains[chainId] = state
+	}
+	return state.since, stallTime > 0 && now-state.since >= stallTime
+}
+
+// Stats derives the per node and fleet finality lag of every chain
+func (f *Finalities) Stats(reports map[string]*Stats, stallTime int64) []ChainFinality {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	chains := make(map[string]*ChainFinality)
+	for id, stats := range reports {
+		if stats.Finality == nil || stats.Block == nil || stats.Status == StatusWrongNetwork {
+			continue
+		}
+		chain := chains[stats.ChainId]
+		if chain == nil {
+			chain = &ChainFinality{ChainId: stats.ChainId}
+			chains[stats.ChainId] = chain
+		}
+		node := NodeFinality{
+			Id:        id,
+			Latest:    stats.Block.Number,
+			Safe:      stats.Finality.Safe,
+			Finalized: stats.Finality.Finalized,
+		}
+		if node.Latest > node.Finalized {
+			node.FinalizedLag = node.Latest - node.Finalized
+		}
+		chain.Nodes = append(chain.Nodes, node)
+		if node.Latest > chain.Latest {
+			chain.Latest = node.Latest
+		}
+		if node.Safe > chain.Safe {
+			chain.Safe = node.Safe
+		}
+		if node.Finalized > chain.Finalized {
+			chain.Finalized = node.Finalized
+		}
+	}
+
+	now := time.Now().Unix()
+	result := make([]ChainFinality, 0, len(chains))
+	for chainId, chain := range chains {
+		if chain.Latest > chain.Finalized {
+			chain.FinalizedLag = chain.Latest - chain.Finalized
+		}
+		if state := f.chains[chainId]; state != nil {
+			chain.FinalizedSince = state.since
+			chain.Stalled = stallTime > 0 && now-state.since >= stallTime
+		}
+		sort.Slice(chain.Nodes, func(i, j int) bool {
+			return chain.Nodes[i].Id < chain.Nodes[j].Id
+		})
+		result = append(result, *chain)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChainId < result[j].ChainId
+	})
+	return result
+}
@@ -0,0 +1,37 @@
+package model
+
+import "testing"
+
+func TestFinalities(t *testing.T) {
+	finalities := NewFinalities()
+	if _, stalled := finalities.Update("1", 100, 60); stalled {
+		t.Fatal("fresh finalized head marked as stalled")
+	}
+	// a lagging node must not move the finalized head back
+	finalities.Update("1", 90, 60)
+	finalities.chains["1"].since -= 61
+	if _, stalled := finalities.Update("1", 100, 60); !stalled {
+		t.Fatal("finalized head not advanced must be stalled")
+	}
+	if _, stalled := finalities.Update("1", 100, 0); stalled {
+		t.Fatal("stall time 0 should disable the check")
+	}
+	finalities.chains["1"].since += 61
+
+	reports := map[string]*Stats{
+		"a": {ChainId: "1", Block: &Block{Number: 164}, Finality: &Finality{Safe: 132, Finalized: 100}},
+		"b": {ChainId: "1", Block: &Block{Number: 150}, Finality: &Finality{Safe: 120, Finalized: 90}},
+		"c": {ChainId: "1", Block: &Block{Number: 999}, Finality: &Finality{Finalized: 1}, Status: StatusWrongNetwork},
+	}
+	stats := finalities.Stats(reports, 60)
+	if len(stats) != 1 {
+		t.Fatalf("chain count mismatch: have %d, want 1", len(stats))
+	}
+	chain := stats[0]
+	if chain.Latest != 164 || chain.Finalized != 100 || chain.FinalizedLag != 64 || chain.Stalled {
+		t.Errorf("unexpected chain finality: %+v", chain)
+	}
+	if len(chain.Nodes) != 2 || chain.Nodes[1].FinalizedLag != 60 {
+		t.Errorf("unexpected node finality: %+v", chain.Nodes)
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"sync"
+	"time"
+)
+
+// historySize is the number of stats points kept per node, about an hour of reports
+const historySize = 360
+
+// HistoryPoint is the summary of a stats report in the node history
+type HistoryPoint struct {
+	Time      int64 // unix second
+	Block     uint64
+	PeerCount uint64
+	Pending   uint
+	GasPrice  *math.Decimal256
+	Syncing   bool
+	Status    string
+}
+
+// History keeps the recent stats points of every node, the history outlives the connection
+// so a restarted node can be looked at
+type History struct {
+	lock  sync.RWMutex
+	nodes map[string][]HistoryPoint
+}
+
+// NewHistory creates an empty stats history
+func NewHistory() *History {
+	return &History{nodes: make(map[string][]HistoryPoint)}
+}
+
+// Add appends the stats report of the node
+func (h *History) Add(id string, stats *Stats) {
+	point := HistoryPoint{
+		Time:      time.Now().Unix(),
+		PeerCount: stats.PeerCount,
+		Pending:   stats.Pending,
+		GasPrice:  stats.GasPrice,
+		Syncing:   stats.Syncing,
+		Status:    stats.Status,
+	}
+	if stats.Block != nil {
+		point.Block = stats.Block.Number
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	points := append(h.nodes[id], point)
+	if len(points) > historySize {
+		points = points[len(points)-historySize:]
+	}
+	h.nodes[id] = points
+}
+
+// Range returns the points of the node between from and to, unix seconds inclusive, a zero
+// bound is open
+func (h *History) Range(id string, from, to int64) []HistoryPoint {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	points := make([]HistoryPoint, 0)
+	for _, point := range h.nodes[id] {
+		if (from > 0 && point.Time < from) || (to > 0 && point.Time > to) {
+			continue
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// Has reports whether the node has any history
+func (h *History) Has(id string) bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.nodes[id]) > 0
+}
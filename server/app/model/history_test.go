@@ -0,0 +1,24 @@
+package model
+
+import "testing"
+
+func TestHistory(t *testing.T) {
+	history := NewHistory()
+	if history.Has("a") {
+		t.Fatal("empty history has a node")
+	}
+	for i := 0; i < historySize+10; i++ {
+		history.Add("a", &Stats{PeerCount: uint64(i), Block: &Block{Number: uint64(i)}})
+	}
+	points := history.Range("a", 0, 0)
+	if len(points) != historySize || points[0].Block != 10 {
+		t.Fatalf("history not bounded: %d points, first block %d", len(points), points[0].Block)
+	}
+	now := points[len(points)-1].Time
+	if points := history.Range("a", now+1, 0); len(points) != 0 {
+		t.Errorf("points after the range returned: %d", len(points))
+	}
+	if points := history.Range("a", 0, now); len(points) != historySize {
+		t.Errorf("points in the range missing: %d", len(points))
+	}
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyHistorySize is the number of latency reports kept per node
+const latencyHistorySize = 60
+
+// NodeLatency is the latency message emitted by the node
+type NodeLatency struct {
+	ID      string          `json:"id"`
+	Latency string          `json:"latency"`
+	Quality *LatencyQuality `json:"quality"`
+}
+
+// LatencyQuality is the quality of the node round-trips over its latest pings, in milliseconds
+type LatencyQuality struct {
+	Samples      int
+	Min          int64
+	Avg          float64
+	Max          int64
+	Jitter       float64
+	P95          int64
+	TimeoutRatio float64
+}
+
+// LatencyPoint is a latency report in the node history
+type LatencyPoint struct {
+	Time         int64
+	Avg          float64
+	P95          int64
+	Jitter       float64
+	TimeoutRatio float64
+	Degraded     bool
+}
+
+// LatencyStats is the latest latency quality of a node with its history
+type LatencyStats struct {
+	Id       string
+	Quality  LatencyQuality
+	History  []LatencyPoint
+	Degraded bool // the latest reports all exceeded the thresholds
+}
+
+// Latencies tracks the latency history of the nodes to find the sustained degradations
+type Latencies struct {
+	lock  sync.RWMutex
+	nodes map[string]*LatencyStats
+}
+
+// NewLatencies creates an empty latency tracker
+func NewLatencies() *Latencies {
+	return &Latencies{nodes: make(map[string]*LatencyStats)}
+}
+
+// Update records the latency quality of the node. A report is degraded if its p95 exceeds p95
+// milliseconds or its timeout ratio exceeds timeoutRatio, the node is degraded once the latest
+// sustain reports are, so a single spike doesn't count. A 0 threshold or sustain disables it
+func (l *Latencies) Update(id string, quality *LatencyQuality, p95 int64, timeoutRatio float64, sustain int) LatencyStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	stats := l.nodes[id]
+	if stats == nil {
+		stats = &LatencyStats{Id: id}
+		l.nodes[id] = stats
+	}
+	stats.Quality = *quality
+	stats.History = append(stats.History, LatencyPoint{
+		Time:         time.Now().Unix(),
+		Avg:          quality.Avg,
+		P95:          quality.P95,
+		Jitter:       quality.Jitter,
+		TimeoutRatio: quality.TimeoutRatio,
+		Degraded:     (p95 > 0 && quality.P95 > p95) || (timeoutRatio > 0 && quality.TimeoutRatio > timeoutRatio),
+	})
+	if len(stats.History) > latencyHistorySize {
+		stats.History = stats.History[len(stats.History)-latencyHistorySize:]
+	}
+
+	stats.Degraded = sustain > 0 && len(stats.History) >= sustain
+	if stats.Degraded {
+		for _, point := range stats.History[len(stats.History)-sustain:] {
+			if !point.Degraded {
+				stats.Degraded = false
+				break
+			}
+		}
+	}
+	return *stats
+}
+
+// Get returns the latency stats of the node, nil if the node has not reported its latency
+func (l *Latencies) Get(id string) *LatencyStats {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	stats := l.nodes[id]
+	if stats == nil {
+		return nil
+	}
+	copied := *stats
+	copied.History = append([]LatencyPoint(nil), stats.History...)
+	return &copied
+}
+
+// Delete remove the latency history of a disconnected node
+func (l *Latencies) Delete(id string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.nodes, id)
+}
+
+// All returns the latency stats of all nodes
+func (l *Latencies) All() []LatencyStats {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	all := make([]LatencyStats, 0, len(l.nodes))
+	for _, stats := range l.nodes {
+		stats := *stats
+		stats.History = append([]LatencyPoint(nil), stats.History...)
+		all = append(all, stats)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Id < all[j].Id
+	})
+	return all
+}
@@ -0,0 +1,34 @@
+package model
+
+import "testing"
+
+func TestLatencies(t *testing.T) {
+	latencies := NewLatencies()
+	slow := &LatencyQuality{Samples: 60, Avg: 900, P95: 1500}
+	fast := &LatencyQuality{Samples: 60, Avg: 40, P95: 60}
+
+	// a single spike is not a degradation
+	latencies.Update("a", fast, 1000, 0.1, 3)
+	if stats := latencies.Update("a", slow, 1000, 0.1, 3); stats.Degraded {
+		t.Fatal("single spike marked as degraded")
+	}
+	latencies.Update("a", slow, 1000, 0.1, 3)
+	if stats := latencies.Update("a", slow, 1000, 0.1, 3); !stats.Degraded || len(stats.History) != 4 {
+		t.Fatalf("sustained degradation not detected: %+v", stats)
+	}
+	lossy := &LatencyQuality{Samples: 60, Avg: 40, P95: 60, TimeoutRatio: 0.5}
+	if stats := latencies.Update("a", lossy, 1000, 0.1, 3); !stats.Degraded {
+		t.Error("timeouts must count as degradation")
+	}
+	if stats := latencies.Update("a", fast, 1000, 0.1, 3); stats.Degraded {
+		t.Error("recovered node still degraded")
+	}
+	if stats := latencies.Update("a", slow, 1000, 0.1, 0); stats.Degraded {
+		t.Error("sustain 0 should disable the degradation")
+	}
+
+	latencies.Delete("a")
+	if len(latencies.All()) != 0 {
+		t.Error("deleted node still tracked")
+	}
+}
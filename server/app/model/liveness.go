@@ -0,0 +1,107 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// StaleNode is a connected node that sent no stats within the stale window
+type StaleNode struct {
+	Id        string
+	LoginTime int64 // unix second
+	LastStats int64 // unix second, 0 if the node never sent stats
+	Silent    int64 // seconds since the last stats, or since the login
+}
+
+type liveness struct {
+	login time.Time
+	stats time.Time
+	stale bool // reported as stale, until the next stats
+}
+
+// Liveness tracks when the connected nodes last sent stats, the websocket may stay open while
+// the node itself is frozen
+type Liveness struct {
+	lock  sync.RWMutex
+	nodes map[string]*liveness
+}
+
+// NewLiveness creates an empty liveness tracker
+func NewLiveness() *Liveness {
+	return &Liveness{nodes: make(map[string]*liveness)}
+}
+
+// Login records a newly authenticated node
+func (l *Liveness) Login(id string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.nodes[id] = &liveness{login: time.Now()}
+}
+
+// Report records the stats of the node, and whether it recovered from the stale state
+func (l *Liveness) Report(id string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	node := l.nodes[id]
+	if node == nil {
+		return false
+	}
+	node.stats = time.Now()
+	recovered := node.stale
+	node.stale = false
+	return recovered
+}
+
+// Delete remove a disconnected node
+func (l *Liveness) Delete(id string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.nodes, id)
+}
+
+// Stale returns the nodes without stats for window seconds, none if the window is disabled
+func (l *Liveness) Stale(window int64) []StaleNode {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.stale(window, false)
+}
+
+// MarkStale marks the nodes without stats for window seconds as stale, and returns the ones
+// that weren't stale before
+func (l *Liveness) MarkStale(window int64) []StaleNode {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	newly := l.stale(window, true)
+	for _, node := range newly {
+		l.nodes[node.Id].stale = true
+	}
+	return newly
+}
+
+func (l *Liveness) stale(window int64, onlyNew bool) []StaleNode {
+	stale := make([]StaleNode, 0)
+	if window <= 0 {
+		return stale
+	}
+	now := time.Now()
+	for id, node := range l.nodes {
+		last := node.login
+		if !node.stats.IsZero() {
+			last = node.stats
+		}
+		silent := int64(now.Sub(last).Seconds())
+		if silent < window || (onlyNew && node.stale) {
+			continue
+		}
+		staleNode := StaleNode{Id: id, LoginTime: node.login.Unix(), Silent: silent}
+		if !node.stats.IsZero() {
+			staleNode.LastStats = node.stats.Unix()
+		}
+		stale = append(stale, staleNode)
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].Id < stale[j].Id
+	})
+	return stale
+}
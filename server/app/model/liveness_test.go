@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLiveness(t *testing.T) {
+	liveness := NewLiveness()
+	liveness.Login("a")
+	liveness.Login("b")
+	liveness.Report("b")
+	if stale := liveness.Stale(60); len(stale) != 0 {
+		t.Fatalf("fresh nodes marked as stale: %+v", stale)
+	}
+
+	// age the login of a and the stats of b
+	liveness.nodes["a"].login = time.Now().Add(-2 * time.Minute)
+	liveness.nodes["b"].stats = time.Now().Add(-90 * time.Second)
+	stale := liveness.Stale(60)
+	if len(stale) != 2 || stale[0].LastStats != 0 || stale[0].Silent < 120 || stale[1].LastStats == 0 {
+		t.Fatalf("unexpected stale nodes: %+v", stale)
+	}
+
+	if newly := liveness.MarkStale(60); len(newly) != 2 {
+		t.Fatalf("stale nodes not marked: %+v", newly)
+	}
+	if newly := liveness.MarkStale(60); len(newly) != 0 {
+		t.Fatalf("stale nodes marked twice: %+v", newly)
+	}
+	if !liveness.Report("b") || liveness.Report("b") {
+		t.Error("recovery must be reported once")
+	}
+
+	liveness.Delete("a")
+	if stale := liveness.Stale(60); len(stale) != 0 {
+		t.Errorf("unexpected stale nodes after delete and report: %+v", stale)
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import "sort"
+
+// PeerStats is the peer composition of every node reporting its peers, and the links
+// between the monitored nodes
+type PeerStats struct {
+	Nodes []PeerComposition
+	Links []PeerLink
+}
+
+// PeerComposition summarizes the peers of a node
+type PeerComposition struct {
+	Id           string
+	Total        int
+	Inbound      int
+	InboundRatio float64
+	Clients      map[string]int // peer count by client name, the client diversity
+}
+
+// PeerLink means the two monitored nodes are directly peered, the ids are sorted
+type PeerLink struct {
+	A string
+	B string
+}
+
+// CalcPeerStats aggregates the peer lists of the nodes, a link is found when the peer id
+// matches the self id reported by another monitored node
+func CalcPeerStats(reports map[string]*Stats) PeerStats {
+	selfs := make(map[string]string)
+	for id, stats := range reports {
+		if stats.Peers != nil && stats.Peers.Self != "" {
+			selfs[stats.Peers.Self] = id
+		}
+	}
+
+	stats := PeerStats{Nodes: []PeerComposition{}, Links: []PeerLink{}}
+	links := make(map[PeerLink]bool)
+	for id, report := range reports {
+		if report.Peers == nil {
+			continue
+		}
+		composition := PeerComposition{
+			Id:      id,
+			Total:   len(report.Peers.List),
+			Clients: make(map[string]int),
+		}
+		for _, peer := range report.Peers.List {
+			if peer.Inbound {
+				composition.Inbound++
+			}
+			client := peer.Client
+			if client == "" {
+				client = "unknown"
+			}
+			composition.Clients[client]++
+
+			if other, ok := selfs[peer.Id]; ok && other != id {
+				link := PeerLink{A: id, B: other}
+				if link.B < link.A {
+					link.A, link.B = link.B, link.A
+				}
+				links[link] = true
+			}
+		}
+		if composition.Total > 0 {
+			composition.InboundRatio = float64(composition.Inbound) / float64(composition.Total)
+		}
+		stats.Nodes = append(stats.Nodes, composition)
+	}
+	for link := range links {
+		stats.Links = append(stats.Links, link)
+	}
+
+	sort.Slice(stats.Nodes, func(i, j int) bool {
+		return stats.Nodes[i].Id < stats.Nodes[j].Id
+	})
+	sort.Slice(stats.Links, func(i, j int) bool {
+		if stats.Links[i].A != stats.Links[j].A {
+			return stats.Links[i].A < stats.Links[j].A
+		}
+		return stats.Links[i].B < stats.Links[j].B
+	})
+	return stats
+}
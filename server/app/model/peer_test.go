@@ -0,0 +1,31 @@
+package model
+
+import "testing"
+
+func TestCalcPeerStats(t *testing.T) {
+	reports := map[string]*Stats{
+		"a": {Peers: &Peers{Self: "aa", List: []Peer{
+			{Id: "bb", Client: "Geth", Inbound: true},
+			{Id: "x1", Client: "Nethermind"},
+			{Id: "x2", Client: "Geth"},
+			{Id: "x3"},
+		}}},
+		"b": {Peers: &Peers{Self: "bb", List: []Peer{{Id: "aa", Client: "Geth"}}}},
+		"c": {Peers: &Peers{Self: "cc"}},
+		"d": {},
+	}
+	stats := CalcPeerStats(reports)
+	if len(stats.Nodes) != 3 {
+		t.Fatalf("node count mismatch: have %d, want 3", len(stats.Nodes))
+	}
+	a := stats.Nodes[0]
+	if a.Id != "a" || a.Total != 4 || a.Inbound != 1 || a.InboundRatio != 0.25 {
+		t.Errorf("unexpected composition: %+v", a)
+	}
+	if a.Clients["Geth"] != 2 || a.Clients["Nethermind"] != 1 || a.Clients["unknown"] != 1 {
+		t.Errorf("unexpected client diversity: %v", a.Clients)
+	}
+	if len(stats.Links) != 1 || stats.Links[0] != (PeerLink{A: "a", B: "b"}) {
+		t.Errorf("unexpected links: %+v", stats.Links)
+	}
+}
@@ -2,17 +2,33 @@ package model
 
 import (
 	"ethstats/common/util/connutil"
+	"time"
 )
 
 // NodePing contains the last time the node is alive
 type NodePing struct {
 	ID         string `json:"id"`
 	Time       string `json:"clientTime"`
+	SentAt     int64  `json:"sentAt"` //unix毫秒，客户端发送时间
 	NodeStatus string `json:"nodeStatus"`
 }
 
-// SendResponse send the pong response to the node
-func (n *NodePing) SendResponse(c *connutil.ConnWrapper) error {
+// NodePong echoes the ping send time with the server receive and send time, so the node can
+// estimate its clock offset. The times are unix milliseconds
+type NodePong struct {
+	ID         string `json:"id"`
+	SentAt     int64  `json:"sentAt"`
+	ReceivedAt int64  `json:"receivedAt"`
+	SentBackAt int64  `json:"sentBackAt"`
+}
+
+// SendResponse send the pong response to the node, receivedAt is when the ping was read
+func (n *NodePing) SendResponse(c *connutil.ConnWrapper, receivedAt time.Time) error {
 	// message type is always 1
-	return c.WriteJSON(map[string][]interface{}{"emit": {"node-pong", n.ID}})
+	return c.WriteJSON(map[string][]interface{}{"emit": {"node-pong", NodePong{
+		ID:         n.ID,
+		SentAt:     n.SentAt,
+		ReceivedAt: receivedAt.UnixMilli(),
+		SentBackAt: time.Now().UnixMilli(),
+	}}})
 }
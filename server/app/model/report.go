@@ -0,0 +1,60 @@
+package model
+
+import "sync"
+
+// Reports keeps the latest parsed stats report of every authenticated node, keyed by node id
+type Reports struct {
+	lock  sync.RWMutex
+	stats map[string]*Stats
+}
+
+// NewReports creates an empty report store
+func NewReports() *Reports {
+	return &Reports{stats: make(map[string]*Stats)}
+}
+
+// Set replace the latest report of the node
+func (r *Reports) Set(id string, stats *Stats) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.stats[id] = stats
+}
+
+// Get returns the latest report of the node, nil if the node has not reported
+func (r *Reports) Get(id string) *Stats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.stats[id]
+}
+
+// Delete remove the report of a disconnected node
+func (r *Reports) Delete(id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.stats, id)
+}
+
+// All returns a copy of the latest reports, the reports themselves must not be modified
+func (r *Reports) All() map[string]*Stats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	all := make(map[string]*Stats, len(r.stats))
+	for id, stats := range r.stats {
+		all[id] = stats
+	}
+	return all
+}
+
+// Fleet returns the latest reports the fleet stats are computed from, a node on the wrong
+// network is still listed with All but left out of them
+func (r *Reports) Fleet() map[string]*Stats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	fleet := make(map[string]*Stats, len(r.stats))
+	for id, stats := range r.stats {
+		if stats.Status != StatusWrongNetwork {
+			fleet[id] = stats
+		}
+	}
+	return fleet
+}
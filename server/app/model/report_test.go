@@ -0,0 +1,20 @@
+package model
+
+import "testing"
+
+func TestReportsFleet(t *testing.T) {
+	reports := NewReports()
+	reports.Set("n1", &Stats{Status: "ok", NodeInfo: Node{ClientName: "Geth", ClientVersion: "v1.13.5"}})
+	reports.Set("n2", &Stats{Status: StatusWrongNetwork, NodeInfo: Node{ClientName: "Geth", ClientVersion: "v1.10.0"}})
+
+	if all := reports.All(); len(all) != 2 {
+		t.Fatalf("the node on the wrong network should still be listed: %v", all)
+	}
+	fleet := reports.Fleet()
+	if len(fleet) != 1 || fleet["n1"] == nil {
+		t.Fatalf("unexpected fleet: %v", fleet)
+	}
+	if versions := CalcVersionStats(fleet); len(versions) != 1 || versions[0].Drift {
+		t.Fatalf("the node on the wrong network skews the versions: %+v", versions)
+	}
+}
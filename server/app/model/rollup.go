@@ -0,0 +1,64 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// RollupStats is the rollup state of a L2 node, and since when its safe head didn't advance
+type RollupStats struct {
+	Id        string
+	Rollup    Rollup
+	SafeSince int64 // unix second the safe head last advanced
+	Stalled   bool
+}
+
+// Rollups tracks the safe head of the L2 nodes to find the stalled ones
+type Rollups struct {
+	lock  sync.RWMutex
+	nodes map[string]*RollupStats
+}
+
+// NewRollups creates an empty rollup tracker
+func NewRollups() *Rollups {
+	return &Rollups{nodes: make(map[string]*RollupStats)}
+}
+
+// Update records the rollup state of the node, the node is stalled if the safe head didn't
+// advance within stallTime seconds, 0 disables the check
+func (r *Rollups) Update(id string, rollup *Rollup, stallTime int64) RollupStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now().Unix()
+	stats := r.nodes[id]
+	if stats == nil || rollup.SafeL2 != stats.Rollup.SafeL2 {
+		stats = &RollupStats{Id: id, SafeSince: now}
+		r.nodes[id] = stats
+	}
+	stats.Rollup = *rollup
+	stats.Stalled = stallTime > 0 && now-stats.SafeSince >= stallTime
+	return *stats
+}
+
+// Delete remove the rollup state of a disconnected node
+func (r *Rollups) Delete(id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.nodes, id)
+}
+
+// All returns the rollup state of all L2 nodes
+func (r *Rollups) All() []RollupStats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	all := make([]RollupStats, 0, len(r.nodes))
+	for _, stats := range r.nodes {
+		all = append(all, *stats)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Id < all[j].Id
+	})
+	return all
+}
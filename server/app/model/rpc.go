@@ -0,0 +1,34 @@
+package model
+
+// rpcMinCalls is the number of calls a method needs in the window before it is judged,
+// so a single slow call after startup doesn't raise an alert
+const rpcMinCalls = 5
+
+// RpcMetric is the health of a node rpc method over the latest calls
+type RpcMetric struct {
+	Method    string
+	Calls     int
+	Errors    int
+	Timeouts  int
+	ErrorRate float64
+	P50       float64 // milliseconds
+	P95       float64
+	P99       float64
+}
+
+// CheckRpc returns the methods whose p95 latency exceeds latency milliseconds and the methods
+// whose error rate exceeds errorRate, a threshold of zero disables the check
+func CheckRpc(metrics []RpcMetric, latency int64, errorRate float64) (slow, failing []RpcMetric) {
+	for _, metric := range metrics {
+		if metric.Calls < rpcMinCalls {
+			continue
+		}
+		if errorRate > 0 && metric.ErrorRate > errorRate {
+			failing = append(failing, metric)
+		}
+		if latency > 0 && metric.P95 > float64(latency) {
+			slow = append(slow, metric)
+		}
+	}
+	return slow, failing
+}
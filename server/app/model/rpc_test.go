@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestCheckRpc(t *testing.T) {
+	metrics := []RpcMetric{
+		{Method: "eth_blockNumber", Calls: 100, P95: 20},
+		{Method: "eth_getBlockByNumber", Calls: 100, P95: 3000},
+		{Method: "eth_gasPrice", Calls: 100, Errors: 50, ErrorRate: 0.5, P95: 10},
+		{Method: "txpool_status", Calls: 2, Errors: 2, ErrorRate: 1, P95: 5000},
+	}
+	slow, failing := CheckRpc(metrics, 2000, 0.2)
+	if len(slow) != 1 || slow[0].Method != "eth_getBlockByNumber" {
+		t.Errorf("unexpected slow methods: %+v", slow)
+	}
+	if len(failing) != 1 || failing[0].Method != "eth_gasPrice" {
+		t.Errorf("unexpected failing methods: %+v", failing)
+	}
+	if slow, failing = CheckRpc(metrics, 0, 0); len(slow) != 0 || len(failing) != 0 {
+		t.Errorf("disabled checks must not report methods: %+v %+v", slow, failing)
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import "sort"
+
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// NodeSecurity is the latest audit findings of a node
+type NodeSecurity struct {
+	Id       string
+	Time     int64
+	Findings []AuditFinding
+}
+
+// CalcSecurity lists the audit findings of the nodes having any
+func CalcSecurity(reports map[string]*Stats) []NodeSecurity {
+	result := []NodeSecurity{}
+	for id, stats := range reports {
+		if stats.Security == nil || len(stats.Security.Findings) == 0 {
+			continue
+		}
+		result = append(result, NodeSecurity{
+			Id:       id,
+			Time:     stats.Security.Time,
+			Findings: stats.Security.Findings,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
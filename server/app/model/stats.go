@@ -0,0 +1,198 @@
+package model
+
+import "github.com/ethereum/go-ethereum/common/math"
+
+const (
+	StatusOK           = "ok"
+	StatusWrongNetwork = "wrong-network"
+)
+
+// Stats is the stats report emitted by the node, it mirrors the client model
+type Stats struct {
+	Status    string
+	Mismatch  []string
+	Active    bool
+	PeerCount uint64
+	Peers     *Peers
+	Pending   uint
+	Txpool    *Txpool
+	GasPrice  *math.Decimal256
+	Fee       *Fee
+	Syncing   bool
+	Sync      *SyncProgress
+	ChainId   string
+	NodeInfo  Node
+	Block     *Block
+	Finality  *Finality
+	Security  *Audit
+	Rollup    *Rollup
+	Rpc       []RpcMetric
+	Endpoint  *Endpoint
+	Errors    map[string]string
+	Clock     *Clock
+}
+
+// Node is the identity of the reporting node
+type Node struct {
+	Id            string
+	Name          string
+	Contact       string
+	Coinbase      string
+	Node          string
+	Enr           string
+	NodeId        string
+	Net           string
+	ChainId       string
+	Genesis       string
+	Protocol      string
+	Api           string
+	ChainPort     string
+	DiscoveryPort string
+	OSPlatform    string
+	OS            string
+	Client        string
+	ClientName    string
+	ClientVersion string
+	Agent         string
+	Labels        []string
+}
+
+// Peers is the admin_peers list reported by the node
+type Peers struct {
+	Self string
+	List []Peer
+}
+
+// Peer is a connected peer of the node
+type Peer struct {
+	Id         string
+	RemoteAddr string
+	Name       string
+	Client     string
+	Version    string
+	Inbound    bool
+	Protocols  map[string]int
+}
+
+// Txpool is the mempool state reported by the node
+type Txpool struct {
+	Pending uint64
+	Queued  uint64
+	Inspect *TxpoolInspect
+}
+
+// TxpoolInspect is the txpool_inspect summary reported by the node
+type TxpoolInspect struct {
+	TopSenders          []TxpoolSender
+	GasPricePercentiles []float64
+	GasPrice            []*math.Decimal256
+}
+
+// TxpoolSender is the pending and queued transaction count of a sender
+type TxpoolSender struct {
+	Address string
+	Pending int
+	Queued  int
+}
+
+// Fee is the fee market report built from eth_feeHistory by the node
+type Fee struct {
+	OldestBlock      uint64
+	LatestBlock      uint64
+	BaseFee          []*math.Decimal256
+	NextBaseFee      *math.Decimal256
+	Percentiles      []float64
+	PriorityFee      []*math.Decimal256
+	GasUsedRatio     []float64
+	BlobBaseFee      *math.Decimal256
+	BlobGasUsedRatio []float64
+}
+
+// Audit is the rpc exposure audit reported by the node
+type Audit struct {
+	Time     int64
+	Findings []AuditFinding
+}
+
+// AuditFinding is a risk found by the audit
+type AuditFinding struct {
+	Check    string
+	Severity string
+	Message  string
+}
+
+// Rollup is the L2 specific health reported by the chain adapter of the node
+type Rollup struct {
+	Type         string
+	UnsafeL2     uint64
+	SafeL2       uint64
+	FinalizedL2  uint64
+	L1Head       uint64
+	L1Origin     uint64
+	L1Lag        uint64
+	BatchL2      uint64
+	BatchLag     uint64
+	BatchLagTime uint64
+}
+
+// Finality is the safe and finalized block of the node
+type Finality struct {
+	Safe          uint64
+	SafeHash      string
+	Finalized     uint64
+	FinalizedHash string
+}
+
+// SyncProgress is the eth_syncing progress of the node
+type SyncProgress struct {
+	StartingBlock uint64
+	CurrentBlock  uint64
+	HighestBlock  uint64
+
+	PulledStates uint64
+	KnownStates  uint64
+
+	SyncedAccounts  uint64
+	SyncedBytecodes uint64
+	SyncedStorage   uint64
+
+	HealedTrienodes  uint64
+	HealedBytecodes  uint64
+	HealingTrienodes uint64
+	HealingBytecode  uint64
+}
+
+// Block is the latest block header seen by the node
+type Block struct {
+	Number          uint64
+	Hash            string
+	ParentHash      string
+	Miner           string
+	Difficulty      *math.Decimal256
+	GasUsed         uint64
+	GasLimit        uint64
+	BaseFee         *math.Decimal256
+	TxCount         int
+	UncleCount      int
+	WithdrawalCount int
+	BlobGasUsed     *uint64
+	ExcessBlobGas   *uint64
+	Size            uint64
+	Time            uint64
+}
+
+// Endpoint is the node rpc endpoint in use, and how often the client failed over
+type Endpoint struct {
+	Url          string
+	Priority     int
+	Total        int
+	Failovers    int
+	LastFailover int64
+}
+
+// Clock is the clock offset of the node host to the server, positive if the node is behind
+type Clock struct {
+	Offset int64 // milliseconds
+	Rtt    int64 // milliseconds
+	Time   int64
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// syncRateWeight is the weight of the newest sample in the smoothed sync rate
+const syncRateWeight = 0.3
+
+// SyncStats is the sync progress of a syncing node with the derived rate and ETA
+type SyncStats struct {
+	Id       string
+	Progress SyncProgress
+	Percent  float64 // 0~100
+	Rate     float64 // blocks per second, smoothed
+	ETA      int64   // seconds to reach the highest block, -1 if unknown
+}
+
+type syncState struct {
+	stats SyncStats
+	time  time.Time
+}
+
+// Syncs tracks the sync progress of the syncing nodes
+type Syncs struct {
+	lock  sync.RWMutex
+	nodes map[string]*syncState
+	now   func() time.Time // the clock the rate is measured with
+}
+
+// NewSyncs creates an empty sync tracker
+func NewSyncs() *Syncs {
+	return &Syncs{nodes: make(map[string]*syncState), now: time.Now}
+}
+
+// Update records the sync progress of the node, a nil progress means the node is in sync
+func (s *Syncs) Update(id string, progress *SyncProgress) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if progress == nil {
+		delete(s.nodes, id)
+		return
+	}
+	now := s.now()
+	state := s.nodes[id]
+	if state == nil {
+		state = &syncState{stats: SyncStats{Id: id, ETA: -1}}
+		s.nodes[id] = state
+	} else if elapsed := now.Sub(state.time).Seconds(); elapsed > 0 && progress.CurrentBlock >= state.stats.Progress.CurrentBlock {
+		rate := float64(progress.CurrentBlock-state.stats.Progress.CurrentBlock) / elapsed
+		if state.stats.Rate == 0 {
+			state.stats.Rate = rate
+		} else {
+			state.stats.Rate = syncRateWeight*rate + (1-syncRateWeight)*state.stats.Rate
+		}
+	}
+	state.time = now
+	state.stats.Progress = *progress
+
+	// integer division of the progress is always 0 while syncing, so use float
+	state.stats.Percent = 100
+	if progress.HighestBlock > progress.StartingBlock && progress.CurrentBlock >= progress.StartingBlock {
+		state.stats.Percent = float64(progress.CurrentBlock-progress.StartingBlock) / float64(progress.HighestBlock-progress.StartingBlock) * 100
+	}
+	state.stats.ETA = -1
+	if state.stats.Rate > 0 && progress.HighestBlock >= progress.CurrentBlock {
+		state.stats.ETA = int64(float64(progress.HighestBlock-progress.CurrentBlock) / state.stats.Rate)
+	}
+}
+
+// Get returns the sync stats of the node, nil if the node is in sync
+func (s *Syncs) Get(id string) *SyncStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	state := s.nodes[id]
+	if state == nil {
+		return nil
+	}
+	stats := state.stats
+	return &stats
+}
+
+// Delete remove the sync progress of a disconnected node
+func (s *Syncs) Delete(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.nodes, id)
+}
+
+// All returns the sync stats of all syncing nodes
+func (s *Syncs) All() []SyncStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	all := make([]SyncStats, 0, len(s.nodes))
+	for _, state := range s.nodes {
+		all = append(all, state.stats)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Id < all[j].Id
+	})
+	return all
+}
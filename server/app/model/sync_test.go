@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncsProgress(t *testing.T) {
+	syncs := NewSyncs()
+	syncs.Update("a", &SyncProgress{StartingBlock: 100, CurrentBlock: 100, HighestBlock: 100})
+	syncs.Update("b", &SyncProgress{StartingBlock: 0, CurrentBlock: 250, HighestBlock: 1000})
+	all := syncs.All()
+	if len(all) != 2 {
+		t.Fatalf("sync count mismatch: have %d, want 2", len(all))
+	}
+	if all[0].Percent != 100 || all[0].ETA != -1 {
+		t.Errorf("equal starting and highest block mismatch: %+v", all[0])
+	}
+	if all[1].Percent != 25 {
+		t.Errorf("percent mismatch: have %v, want 25", all[1].Percent)
+	}
+
+	// rate of the first interval, then smoothed with the next one
+	now := time.Now()
+	syncs.now = func() time.Time { return now }
+	syncs.Update("c", &SyncProgress{StartingBlock: 0, CurrentBlock: 250, HighestBlock: 1000})
+	now = now.Add(10 * time.Second)
+	syncs.Update("c", &SyncProgress{StartingBlock: 0, CurrentBlock: 350, HighestBlock: 1000})
+	if c := syncs.Get("c"); c.Rate != 10 || c.ETA != 65 {
+		t.Errorf("first rate mismatch: have rate %v eta %d, want 10 65", c.Rate, c.ETA)
+	}
+	now = now.Add(10 * time.Second)
+	syncs.Update("c", &SyncProgress{StartingBlock: 0, CurrentBlock: 550, HighestBlock: 1000})
+	// 0.3*20 + 0.7*10 blocks per second, 450 blocks left
+	if c := syncs.Get("c"); c.Rate != 13 || c.ETA != 34 {
+		t.Errorf("smoothed rate mismatch: have rate %v eta %d, want 13 34", c.Rate, c.ETA)
+	}
+	syncs.Delete("c")
+
+	syncs.Update("b", nil)
+	if all = syncs.All(); len(all) != 1 || all[0].Id != "a" {
+		t.Errorf("synced node not removed: %+v", all)
+	}
+}
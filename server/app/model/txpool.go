@@ -0,0 +1,96 @@
+package model
+
+import "sort"
+
+const (
+	// txpoolOutlierRatio flags a node whose mempool is this many times larger or smaller than the fleet median
+	txpoolOutlierRatio = 3
+	// txpoolOutlierMinDiff ignores the differences of small mempools
+	txpoolOutlierMinDiff = 100
+	// txpoolMinNodes is the least number of nodes for a meaningful median
+	txpoolMinNodes = 3
+)
+
+// TxpoolStats is the fleet mempool state of a chain. A node far off the median often
+// means its tx gossip is broken
+type TxpoolStats struct {
+	ChainId       string
+	Nodes         int
+	MedianPending uint64
+	MedianQueued  uint64
+	Outliers      []TxpoolOutlier
+}
+
+// TxpoolOutlier is a node whose mempool differs sharply from the fleet median
+type TxpoolOutlier struct {
+	Id      string
+	Pending uint64
+	Queued  uint64
+	Reason  string // high or low
+}
+
+// CalcTxpoolStats compares the mempool of every node with the median of the nodes on the same chain
+func CalcTxpoolStats(reports map[string]*Stats) []TxpoolStats {
+	chains := make(map[string][]string)
+	for id, stats := range reports {
+		if stats.Txpool == nil || stats.Status == StatusWrongNetwork {
+			continue
+		}
+		chains[stats.ChainId] = append(chains[stats.ChainId], id)
+	}
+
+	result := make([]TxpoolStats, 0, len(chains))
+	for chainId, ids := range chains {
+		sort.Strings(ids)
+		var pending, queued, total []uint64
+		for _, id := range ids {
+			txpool := reports[id].Txpool
+			pending = append(pending, txpool.Pending)
+			queued = append(queued, txpool.Queued)
+			total = append(total, txpool.Pending+txpool.Queued)
+		}
+		stats := TxpoolStats{
+			ChainId:       chainId,
+			Nodes:         len(ids),
+			MedianPending: medianUint64(pending),
+			MedianQueued:  medianUint64(queued),
+		}
+		if len(ids) >= txpoolMinNodes {
+			median := medianUint64(total)
+			for i, id := range ids {
+				reason := ""
+				switch {
+				case total[i] >= median*txpoolOutlierRatio && total[i]-median >= txpoolOutlierMinDiff:
+					reason = "high"
+				case total[i]*txpoolOutlierRatio <= median && median-total[i] >= txpoolOutlierMinDiff:
+					reason = "low"
+				}
+				if reason != "" {
+					stats.Outliers = append(stats.Outliers, TxpoolOutlier{
+						Id:      id,
+						Pending: pending[i],
+						Queued:  queued[i],
+						Reason:  reason,
+					})
+				}
+			}
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChainId < result[j].ChainId
+	})
+	return result
+}
+
+// medianUint64 returns the median of the values without modifying them
+func medianUint64(values []uint64) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]uint64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted[len(sorted)/2]
+}
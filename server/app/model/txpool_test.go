@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestCalcTxpoolStats(t *testing.T) {
+	reports := map[string]*Stats{
+		"a": {ChainId: "1", Txpool: &Txpool{Pending: 5000, Queued: 200}},
+		"b": {ChainId: "1", Txpool: &Txpool{Pending: 5200, Queued: 100}},
+		"c": {ChainId: "1", Txpool: &Txpool{Pending: 40, Queued: 0}},
+		"d": {ChainId: "1", Txpool: &Txpool{Pending: 30000, Queued: 5000}},
+		"e": {ChainId: "1", Txpool: &Txpool{Pending: 4800, Queued: 300}},
+		"f": {ChainId: "1"},
+		"g": {ChainId: "5", Txpool: &Txpool{Pending: 1}},
+	}
+	stats := CalcTxpoolStats(reports)
+	if len(stats) != 2 {
+		t.Fatalf("chain count mismatch: have %d, want 2", len(stats))
+	}
+	mainnet := stats[0]
+	if mainnet.Nodes != 5 || mainnet.MedianPending != 5000 {
+		t.Fatalf("unexpected median: %+v", mainnet)
+	}
+	if len(mainnet.Outliers) != 2 {
+		t.Fatalf("outlier count mismatch: have %+v", mainnet.Outliers)
+	}
+	if o := mainnet.Outliers[0]; o.Id != "c" || o.Reason != "low" {
+		t.Errorf("unexpected outlier: %+v", o)
+	}
+	if o := mainnet.Outliers[1]; o.Id != "d" || o.Reason != "high" {
+		t.Errorf("unexpected outlier: %+v", o)
+	}
+	if len(stats[1].Outliers) != 0 {
+		t.Errorf("single node chain must not have outliers: %+v", stats[1])
+	}
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClientVersions is the version distribution of an Ethereum client in the fleet
+type ClientVersions struct {
+	Client   string
+	Latest   string              // the newest version running in the fleet
+	Versions map[string][]string // node ids by version
+	Outdated []string            // nodes not running the latest version
+	Drift    bool                // more than one version is running
+}
+
+// CalcVersionStats groups the nodes by client and version to show the version drift
+func CalcVersionStats(reports map[string]*Stats) []ClientVersions {
+	clients := make(map[string]*ClientVersions)
+	for id, stats := range reports {
+		name, version := stats.NodeInfo.ClientName, stats.NodeInfo.ClientVersion
+		if name == "" {
+			continue
+		}
+		client := clients[name]
+		if client == nil {
+			client = &ClientVersions{Client: name, Versions: make(map[string][]string), Outdated: []string{}}
+			clients[name] = client
+		}
+		client.Versions[version] = append(client.Versions[version], id)
+	}
+
+	result := make([]ClientVersions, 0, len(clients))
+	for _, client := range clients {
+		for version, ids := range client.Versions {
+			sort.Strings(ids)
+			if client.Latest == "" || compareVersion(version, client.Latest) > 0 {
+				client.Latest = version
+			}
+		}
+		for version, ids := range client.Versions {
+			if version != client.Latest {
+				client.Outdated = append(client.Outdated, ids...)
+			}
+		}
+		sort.Strings(client.Outdated)
+		client.Drift = len(client.Versions) > 1
+		result = append(result, *client)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Client < result[j].Client
+	})
+	return result
+}
+
+// compareVersion compares the numeric parts of versions like v1.13.5-stable-916d6a44,
+// the suffix is only compared when the numbers are equal
+func compareVersion(a, b string) int {
+	an, as := splitVersion(a)
+	bn, bs := splitVersion(b)
+	for i := 0; i < len(an) || i < len(bn); i++ {
+		var x, y int
+		if i < len(an) {
+			x = an[i]
+		}
+		if i < len(bn) {
+			y = bn[i]
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return strings.Compare(as, bs)
+}
+
+func splitVersion(version string) ([]int, string) {
+	version = strings.TrimPrefix(version, "v")
+	numbers, suffix := version, ""
+	if index := strings.IndexAny(version, "-+"); index >= 0 {
+		numbers, suffix = version[:index], version[index:]
+	}
+	var parts []int
+	for _, part := range strings.Split(numbers, ".") {
+		n, _ := strconv.Atoi(part)
+		parts = append(parts, n)
+	}
+	return parts, suffix
+}
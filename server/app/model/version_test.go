@@ -0,0 +1,27 @@
+package model
+
+import "testing"
+
+func TestCalcVersionStats(t *testing.T) {
+	reports := map[string]*Stats{
+		"a": {NodeInfo: Node{ClientName: "Geth", ClientVersion: "v1.13.5-stable-916d6a44"}},
+		"b": {NodeInfo: Node{ClientName: "Geth", ClientVersion: "v1.13.10-stable"}},
+		"c": {NodeInfo: Node{ClientName: "Geth", ClientVersion: "v1.13.5-stable-916d6a44"}},
+		"d": {NodeInfo: Node{ClientName: "Nethermind", ClientVersion: "v1.25.4"}},
+		"e": {},
+	}
+	stats := CalcVersionStats(reports)
+	if len(stats) != 2 {
+		t.Fatalf("client count mismatch: have %d, want 2", len(stats))
+	}
+	geth := stats[0]
+	if geth.Latest != "v1.13.10-stable" || !geth.Drift {
+		t.Errorf("unexpected geth versions: %+v", geth)
+	}
+	if len(geth.Outdated) != 2 || geth.Outdated[0] != "a" || geth.Outdated[1] != "c" {
+		t.Errorf("unexpected outdated nodes: %v", geth.Outdated)
+	}
+	if stats[1].Drift || len(stats[1].Outdated) != 0 {
+		t.Errorf("single version must not drift: %+v", stats[1])
+	}
+}
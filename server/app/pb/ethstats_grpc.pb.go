@@ -0,0 +1,256 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: ethstats.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Ethstats_ListNodes_FullMethodName  = "/ethstats.Ethstats/ListNodes"
+	Ethstats_GetNode_FullMethodName    = "/ethstats.Ethstats/GetNode"
+	Ethstats_GetHistory_FullMethodName = "/ethstats.Ethstats/GetHistory"
+	Ethstats_Watch_FullMethodName      = "/ethstats.Ethstats/Watch"
+)
+
+// EthstatsClient is the client API for Ethstats service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EthstatsClient interface {
+	// ListNodes returns the latest state of the nodes, all of them without labels
+	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	// GetNode returns the state of a node with its latency and sync progress
+	GetNode(ctx context.Context, in *GetNodeRequest, opts ...grpc.CallOption) (*NodeDetail, error)
+	// GetHistory returns the recent stats points of a node
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
+	// Watch streams the live feed: stats, ping, latency, alerts and node lifecycle events
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Ethstats_WatchClient, error)
+}
+
+type ethstatsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEthstatsClient(cc grpc.ClientConnInterface) EthstatsClient {
+	return &ethstatsClient{cc}
+}
+
+func (c *ethstatsClient) ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error) {
+	out := new(ListNodesResponse)
+	err := c.cc.Invoke(ctx, Ethstats_ListNodes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethstatsClient) GetNode(ctx context.Context, in *GetNodeRequest, opts ...grpc.CallOption) (*NodeDetail, error) {
+	out := new(NodeDetail)
+	err := c.cc.Invoke(ctx, Ethstats_GetNode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethstatsClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, Ethstats_GetHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethstatsClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Ethstats_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ethstats_ServiceDesc.Streams[0], Ethstats_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethstatsWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ethstats_WatchClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type ethstatsWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethstatsWatchClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EthstatsServer is the server API for Ethstats service.
+// All implementations must embed UnimplementedEthstatsServer
+// for forward compatibility
+type EthstatsServer interface {
+	// ListNodes returns the latest state of the nodes, all of them without labels
+	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	// GetNode returns the state of a node with its latency and sync progress
+	GetNode(context.Context, *GetNodeRequest) (*NodeDetail, error)
+	// GetHistory returns the recent stats points of a node
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
+	// Watch streams the live feed: stats, ping, latency, alerts and node lifecycle events
+	Watch(*WatchRequest, Ethstats_WatchServer) error
+	mustEmbedUnimplementedEthstatsServer()
+}
+
+// UnimplementedEthstatsServer must be embedded to have forward compatible implementations.
+type UnimplementedEthstatsServer struct {
+}
+
+func (UnimplementedEthstatsServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedEthstatsServer) GetNode(context.Context, *GetNodeRequest) (*NodeDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNode not implemented")
+}
+func (UnimplementedEthstatsServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedEthstatsServer) Watch(*WatchRequest, Ethstats_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedEthstatsServer) mustEmbedUnimplementedEthstatsServer() {}
+
+// UnsafeEthstatsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EthstatsServer will
+// result in compilation errors.
+type UnsafeEthstatsServer interface {
+	mustEmbedUnimplementedEthstatsServer()
+}
+
+func RegisterEthstatsServer(s grpc.ServiceRegistrar, srv EthstatsServer) {
+	s.RegisterService(&Ethstats_ServiceDesc, srv)
+}
+
+func _Ethstats_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthstatsServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ethstats_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthstatsServer).ListNodes(ctx, req.(*ListNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ethstats_GetNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthstatsServer).GetNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ethstats_GetNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthstatsServer).GetNode(ctx, req.(*GetNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ethstats_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthstatsServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ethstats_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthstatsServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ethstats_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthstatsServer).Watch(m, &ethstatsWatchServer{stream})
+}
+
+type Ethstats_WatchServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type ethstatsWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethstatsWatchServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Ethstats_ServiceDesc is the grpc.ServiceDesc for Ethstats service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ethstats_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ethstats.Ethstats",
+	HandlerType: (*EthstatsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNodes",
+			Handler:    _Ethstats_ListNodes_Handler,
+		},
+		{
+			MethodName: "GetNode",
+			Handler:    _Ethstats_GetNode_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _Ethstats_GetHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Ethstats_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ethstats.proto",
+}
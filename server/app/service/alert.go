@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/json"
+	"ethstats/common/util/emailutil"
+	"ethstats/server/app/model"
+	"fmt"
+	"github.com/bitxx/logger/logbase"
+	"sync"
+	"time"
+)
+
+const (
+	messageAlert string = "alert"
+)
+
+// alertDelay is the interval in which the same alert of a node is not sent again, so a node
+// that keeps failing doesn't hit the mailbox limit
+const alertDelay = time.Hour
+
+// Alerter sends the alerts of the nodes by email and to all registered hub clients
+type Alerter struct {
+	logger     *logbase.Helper
+	channel    *model.Channel
+	lock       sync.Mutex
+	delayCache map[string]time.Time
+}
+
+// NewAlerter creates a new Alerter with required fields
+func NewAlerter(channel *model.Channel, logger *logbase.Helper) *Alerter {
+	return &Alerter{
+		logger:     logger,
+		channel:    channel,
+		delayCache: make(map[string]time.Time),
+	}
+}
+
+// Raise sends the alert unless the same alert of the node was sent within alertDelay.
+// It never blocks, so it's safe to call from the hub loop
+func (a *Alerter) Raise(alert model.Alert) {
+	now := time.Now()
+	flag := fmt.Sprintf("%s-%s", alert.Id, alert.Type)
+	a.lock.Lock()
+	if latest, ok := a.delayCache[flag]; ok && now.Sub(latest) < alertDelay {
+		a.lock.Unlock()
+		return
+	}
+	a.delayCache[flag] = now
+	a.lock.Unlock()
+
+	alert.Time = now.Unix()
+	switch alert.Level {
+	case model.AlertCritical:
+		a.logger.Errorf("alert node[%s] %s: %s", alert.Id, alert.Type, alert.Message)
+	default:
+		a.logger.Warnf("alert node[%s] %s: %s", alert.Id, alert.Type, alert.Message)
+	}
+
+	msg, err := json.Marshal(map[string][]interface{}{"emit": {messageAlert, alert}})
+	if err != nil {
+		a.logger.Errorf("can't encode alert, error: %s", err)
+	} else {
+		select {
+		case a.channel.MsgAlert <- msg:
+		default:
+			a.logger.Warnf("alert queue is full, alert of node[%s] not broadcast", alert.Id)
+		}
+	}
+
+	go func() {
+		content := "node: [" + alert.Id + "] " + alert.Message
+		err := emailutil.SendEmailDefault(fmt.Sprintf("%s-node %s\n", now.Format("2006-01-02 15:04:05"), alert.Type), content)
+		if err != nil {
+			a.logger.Error("email content: ", content, " send error: ", err)
+		} else {
+			a.logger.Info("email send success")
+		}
+	}()
+}
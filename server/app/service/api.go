@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"ethstats/common/util/connutil"
 	"ethstats/common/util/emailutil"
 	"ethstats/server/app/model"
@@ -9,31 +10,46 @@ import (
 	"github.com/bitxx/logger/logbase"
 	"github.com/gorilla/websocket"
 	"net/http"
-	"regexp"
+	"sort"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 )
 
+// apiWriteTimeout bounds the send of a message to a hub client, a client stuck on a dead
+// connection is removed instead of keeping its writer blocked
+const apiWriteTimeout = 10 * time.Second
+
 // Api is the responsible to send node state to registered hub
 type Api struct {
-	logger *logbase.Helper
-	hub    *hub
+	logger       *logbase.Helper
+	hub          *hub
+	sessions     map[string]*sioSession // socket.io sessions, by sid
+	sessionsLock sync.Mutex
 }
 
 // NewApi creates a new Api struct with the required service
 func NewApi(channel *model.Channel, logger *logbase.Helper) *Api {
 	hub := &hub{
-		register: make(chan *connutil.ConnWrapper),
-		logger:   logger,
-		close:    make(chan interface{}),
-		clients:  make(map[*connutil.ConnWrapper]bool),
-		channel:  channel,
+		register:     make(chan *apiClient),
+		unregister:   make(chan clientConn),
+		commands:     make(chan apiCommand),
+		logger:       logger,
+		close:        make(chan interface{}),
+		done:         make(chan struct{}),
+		clients:      make(map[clientConn]*apiClient),
+		addStream:    make(chan *eventStream),
+		removeStream: make(chan *eventStream),
+		streams:      make(map[*eventStream]bool),
+		events:       newEventBuffer(config.ApiConfig.EventBuffer),
+		channel:      channel,
+		writeTimeout: apiWriteTimeout,
 	}
 	go hub.loop()
 	return &Api{
-		logger: logger,
-		hub:    hub,
+		logger:   logger,
+		hub:      hub,
+		sessions: make(map[string]*sioSession),
 	}
 }
 
@@ -43,12 +59,12 @@ func (a *Api) Close() {
 	a.hub.close <- "close"
 }
 
-// HandleRequest handle all request from hub that are not Ethereum nodes
+// HandleRequest handle all request from hub that are not Ethereum nodes, the hub client gets
+// the role granted to the request
 func (a *Api) HandleRequest(w http.ResponseWriter, r *http.Request) {
 	upgradeConn := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+		CheckOrigin:       checkOrigin,
+		EnableCompression: true,
 	}
 	conn, err := connutil.NewUpgradeConn(upgradeConn, w, r)
 	if err != nil {
@@ -56,17 +72,26 @@ func (a *Api) HandleRequest(w http.ResponseWriter, r *http.Request) {
 			r.RemoteAddr, r.Host, r.RequestURI, err)
 		return
 	}
-	a.logger.Infof("connected new client! (host=%s)", r.Host)
-	a.hub.register <- conn
+	role := requestRole(r)
+	a.logger.Infof("connected new client! (host=%s, role=%s)", r.Host, role)
+	a.hub.register <- newApiClient(conn, config.ApiConfig.QueueSize, role)
 }
 
 // hub maintain a list of registered clients to send messages
 type hub struct {
-	register chan *connutil.ConnWrapper
-	logger   *logbase.Helper
-	close    chan interface{}
-	clients  map[*connutil.ConnWrapper]bool
-	channel  *model.Channel
+	register     chan *apiClient
+	unregister   chan clientConn
+	commands     chan apiCommand
+	logger       *logbase.Helper
+	close        chan interface{}
+	done         chan struct{} // closed once the hub quit
+	clients      map[clientConn]*apiClient
+	addStream    chan *eventStream
+	removeStream chan *eventStream
+	streams      map[*eventStream]bool // SSE clients
+	events       *eventBuffer          // latest events to resume the streams
+	channel      *model.Channel
+	writeTimeout time.Duration
 }
 
 // loop loops as the server is alive and send messages to registered clients
@@ -81,7 +106,21 @@ func (h *hub) loop() {
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client] = true
+			// the new client gets the whole state at once instead of waiting for the next tick
+			h.clients[client.conn] = client
+			go h.writeLoop(client)
+			h.syncState(client, h.state(), make(patchCache), time.Now())
+			go h.readLoop(client.conn)
+		case conn := <-h.unregister:
+			if client := h.clients[conn]; client != nil {
+				h.removeClient(client)
+			}
+		case stream := <-h.addStream:
+			h.startStream(stream)
+		case stream := <-h.removeStream:
+			h.stopStream(stream)
+		case cmd := <-h.commands:
+			h.handleCommand(cmd)
 		case ping := <-h.channel.MsgPing:
 			//debug log for show the ping
 			//h.logger.Info("debug log show ping = > ", string(ping))
@@ -92,66 +131,218 @@ func (h *hub) loop() {
 			//h.logger.Info("debug log show latency = > ", string(latency))
 			//use for send to any fronted client
 			h.writeMessage(latency)
+		case alert := <-h.channel.MsgAlert:
+			h.writeMessage(alert)
+		case event := <-h.channel.MsgEvent:
+			h.writeMessage(event)
 		case <-nodesReportTicker.C:
-			if len(h.clients) <= 0 {
+			if len(h.clients) <= 0 && len(h.streams) <= 0 {
 				continue
 			}
-			for _, v := range h.channel.Nodes {
-				//debug log for show the node info
-				//h.logger.Info("debug log show node = > ", string(v))
-				//use for send to any fronted client
-				h.writeMessage(v)
+			entries, patches, now := h.state(), make(patchCache), time.Now()
+			for _, client := range h.clients {
+				h.syncState(client, entries, patches, now)
+			}
+			for _, e := range entries {
+				h.publish(e.emit, e.id, e.value)
 			}
 		case <-nodesMonitorTicker.C:
 			nodeCount := len(h.channel.Nodes)
 			nodeInfo := ""
-			for _, v := range h.channel.Nodes {
-				//node info like this:
-				//{"Active":true,"PeerCount":500,"Pending":94,"GasPrice":1097000000000,"Syncing":false,"NodeInfo":{"Id":"test","Name":"test","Contact":"","Coinbase":"","Node":"","Net":"","Protocol":"","Api":"","ChainPort":"30303","OSPlatform":"amd64","OS":"darwin","Client":"v1.0.0"},"Block":{"Number":484645,"Hash":"0x0036faa4d9b83ec836ebbcd6a98699323c41bdcdedf8d96a21c6dd25b9c41b88","Difficulty":0,"Transactions":null,"Uncles":null,"Time":1690774181}}
-
-				idRe := regexp.MustCompile("Id\\\":(.*?),")
-				idTmp1 := idRe.FindString(string(v))
-				idTmp2 := strings.Replace(idTmp1, "Id\":\"", "", -1)
-				id := strings.Replace(idTmp2, "\",", "", -1)
-
-				re := regexp.MustCompile("Number\\\":(.*?),")
-				heightTmp1 := re.FindString(string(v))
-				heightTmp2 := strings.Replace(heightTmp1, "Number\":", "", -1)
-				latestHigh := strings.Replace(heightTmp2, ",", "", -1)
-
-				//ip := strings.Replace(k, "[::1]", "127.0.0.1", -1)
+			reports := h.channel.Reports.Fleet()
+			ids := make([]string, 0, len(reports))
+			for id := range reports {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				latestHigh := "0"
+				if block := reports[id].Block; block != nil {
+					latestHigh = strconv.FormatUint(block.Number, 10)
+				}
 				nodeInfo = nodeInfo + "--节点ID：" + id + "，块高度：" + latestHigh + "\n"
 			}
 			content := "节点数量：" + strconv.Itoa(nodeCount) + "\n各节点块高度：\n" + nodeInfo
+			if syncs := h.channel.Syncs.All(); len(syncs) > 0 {
+				content = content + "同步中的节点：\n"
+				for _, sync := range syncs {
+					eta := "未知"
+					if sync.ETA >= 0 {
+						eta = (time.Duration(sync.ETA) * time.Second).String()
+					}
+					content = content + fmt.Sprintf("--节点ID：%s，进度：%d/%d（%.2f%%），速度：%.2f块/秒，预计剩余：%s\n",
+						sync.Id, sync.Progress.CurrentBlock, sync.Progress.HighestBlock, sync.Percent, sync.Rate, eta)
+				}
+			}
 			fmt.Println(content)
 			_ = emailutil.SendEmailDefault(fmt.Sprintf("%s-节点监控简报\n", time.Now().Format("2006-01-02 15:04:05")), content)
 		case <-h.close:
 			h.quit()
-			break
+			return
 		}
 	}
 }
 
-// writeMessage to all registered clients. If an error occurs sending a message to a client,
-// then these connection is closed and removed from the pool of registered clients
+// state encodes the raw stats of every node and the fleet stats
+func (h *hub) state() []stateEntry {
+	var entries []stateEntry
+	for addr, v := range h.channel.Nodes {
+		//debug log for show the node info
+		//h.logger.Info("debug log show node = > ", string(v))
+		var content struct {
+			Emit []json.RawMessage `json:"emit"`
+		}
+		if err := json.Unmarshal(v, &content); err != nil || len(content.Emit) < 2 {
+			continue
+		}
+		_, id := messageTarget(v)
+		if id == "" {
+			id = addr
+		}
+		entries = append(entries, stateEntry{emit: messageStats, id: id, value: content.Emit[1], msg: v})
+	}
+	reports := h.channel.Reports.Fleet()
+	emits := []struct {
+		emit  string
+		value interface{}
+	}{
+		{messageChainStats, h.channel.Chains.Stats()},
+		{messageFeeStats, h.channel.Fees.Trends()},
+		{messageTxpool, model.CalcTxpoolStats(reports)},
+		{messagePeerStats, model.CalcPeerStats(reports)},
+		{messageVersions, model.CalcVersionStats(reports)},
+		{messageSecurity, model.CalcSecurity(reports)},
+		{messageRollup, h.channel.Rollups.All()},
+		{messageFinality, h.channel.Finalities.Stats(reports, config.AlertConfig.FinalityStallTime())},
+		{messageSync, h.channel.Syncs.All()},
+		{messageLatencyStats, h.channel.Latencies.All()},
+		{messageStale, h.channel.Liveness.Stale(config.AlertConfig.StaleTime)},
+	}
+	for _, e := range emits {
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			h.logger.Errorf("can't encode %s message, error: %s", e.emit, err)
+			continue
+		}
+		if msg := h.encodeEmit(e.emit, json.RawMessage(value)); msg != nil {
+			entries = append(entries, stateEntry{emit: e.emit, value: value, msg: msg})
+		}
+	}
+	return entries
+}
+
+// writeMessage to all registered clients and streams whose subscription accepts it
 func (h *hub) writeMessage(msg []byte) {
-	for client := range h.clients {
-		err := client.WriteMessage(1, msg)
+	h.publishMessage(msg)
+	emit, id := "", ""
+	for _, client := range h.clients {
+		if client.filtered() {
+			emit, id = messageTarget(msg)
+			break
+		}
+	}
+	for _, client := range h.clients {
+		h.writeTo(client, msg, emit, id)
+	}
+}
+
+// writeTo queues the message to the client if its subscription accepts the emit of the node,
+// an empty emit is always sent. It never blocks: once the queue of a slow client is full, the
+// oldest message is dropped or the client is disconnected, by the configured policy
+func (h *hub) writeTo(client *apiClient, msg []byte, emit, id string) {
+	if client.closed {
+		return
+	}
+	if emit != "" && !client.accepts(emit, id, h.nodeLabels(id)) {
+		return
+	}
+	select {
+	case client.queue <- msg:
+		return
+	default:
+	}
+	if config.ApiConfig.SlowPolicy == config.SlowPolicyDisconnect {
+		h.logger.Warnf("client %s is too slow, disconnect", client.conn.RemoteAddr())
+		h.removeClient(client)
+		return
+	}
+	// the hub is the only sender, so the freed slot can't be taken by another message
+	select {
+	case <-client.queue:
+		client.dropped++
+	default:
+	}
+	client.queue <- msg
+}
+
+// writeLoop sends the queued messages of the client, so a slow client only delays itself. If
+// an error occurs sending a message, or it isn't sent within the write timeout, then these
+// connection is closed and removed from the pool of registered clients
+func (h *hub) writeLoop(client *apiClient) {
+	for msg := range client.queue {
+		_ = client.conn.SetWriteDeadline(time.Now().Add(h.writeTimeout))
+		err := client.conn.WriteMessage(1, msg)
+		// the connection may be written by others, such as the heartbeats
+		_ = client.conn.SetWriteDeadline(time.Time{})
 		if err != nil {
-			h.logger.Infof("Closed connection with client: %s", client.RemoteAddr())
-			// close and delete the client connection and release
-			client.Close()
-			delete(h.clients, client)
+			h.logger.Warnf("can't send message to client %s, %s", client.conn.RemoteAddr(), err)
+			select {
+			case h.unregister <- client.conn:
+			case <-h.done:
+			}
+			// drain until the hub closes the queue
+			for range client.queue {
+			}
+			return
 		}
 	}
 }
 
+// removeClient closes the connection and the queue of the client, the writer exits once the
+// queue is closed
+func (h *hub) removeClient(client *apiClient) {
+	h.logger.Infof("Closed connection with client: %s", client.conn.RemoteAddr())
+	if client.dropped > 0 {
+		h.logger.Warnf("%d messages to client %s were dropped", client.dropped, client.conn.RemoteAddr())
+	}
+	client.conn.Close()
+	client.closed = true
+	close(client.queue)
+	delete(h.clients, client.conn)
+}
+
+// encodeEmit encode the value as an emit message
+func (h *hub) encodeEmit(emit string, value interface{}) []byte {
+	msg, err := json.Marshal(map[string][]interface{}{
+		"emit": {emit, value},
+	})
+	if err != nil {
+		h.logger.Errorf("can't encode %s message, error: %s", emit, err)
+		return nil
+	}
+	return msg
+}
+
+// nodeLabels returns the labels reported by the node
+func (h *hub) nodeLabels(id string) []string {
+	if id == "" {
+		return nil
+	}
+	if stats := h.channel.Reports.Get(id); stats != nil {
+		return stats.NodeInfo.Labels
+	}
+	return nil
+}
+
 func (h *hub) quit() {
 	h.logger.Info("Closing all registered clients")
-	for client := range h.clients {
-		client.Close()
-		delete(h.clients, client)
+	for _, client := range h.clients {
+		h.removeClient(client)
+	}
+	for stream := range h.streams {
+		h.stopStream(stream)
 	}
+	close(h.done)
 	close(h.register)
 	close(h.close)
 }
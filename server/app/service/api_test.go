@@ -0,0 +1,181 @@
+package service
+
+import (
+	"ethstats/common/util/connutil"
+	"ethstats/server/app/model"
+	"fmt"
+	"github.com/bitxx/logger"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestHub creates a hub with slow subscribers, their queues are never drained
+func newTestHub(subscribers, queueSize int) *hub {
+	h := &hub{
+		clients: make(map[clientConn]*apiClient),
+		streams: make(map[*eventStream]bool),
+		events:  newEventBuffer(0),
+		channel: &model.Channel{Reports: model.NewReports()},
+		logger:  logger.NewLogger(),
+	}
+	for i := 0; i < subscribers; i++ {
+		conn := &connutil.ConnWrapper{}
+		h.clients[conn] = newApiClient(conn, queueSize, RoleViewer)
+	}
+	return h
+}
+
+func TestHubSlowSubscribers(t *testing.T) {
+	h := newTestHub(5000, 16)
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		h.writeMessage([]byte(fmt.Sprintf(`{"emit":["node-ping",{"id":"n%d"}]}`, i)))
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("broadcast blocked on slow subscribers: %s", elapsed)
+	}
+	for _, client := range h.clients {
+		if len(client.queue) != 16 || client.dropped != 1000-16 {
+			t.Fatalf("unexpected queue: %d queued, %d dropped", len(client.queue), client.dropped)
+		}
+		// the oldest messages are dropped, the latest kept
+		if first := string(<-client.queue); first != `{"emit":["node-ping",{"id":"n984"}]}` {
+			t.Fatalf("unexpected oldest queued message: %s", first)
+		}
+	}
+}
+
+// testConn is a hub client connection, a stalled one blocks every write until its deadline
+// as a client whose TCP window is full
+type testConn struct {
+	stalled  bool
+	lock     sync.Mutex
+	deadline time.Time
+	writes   atomic.Int64
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newTestConn(stalled bool) *testConn {
+	return &testConn{stalled: stalled, closed: make(chan struct{})}
+}
+
+func (c *testConn) ReadMessage() (int, []byte, error) {
+	<-c.closed
+	return 0, nil, net.ErrClosed
+}
+
+func (c *testConn) WriteMessage(int, []byte) error {
+	if !c.stalled {
+		c.writes.Add(1)
+		return nil
+	}
+	c.lock.Lock()
+	deadline := c.deadline
+	c.lock.Unlock()
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timeout = time.After(time.Until(deadline))
+	}
+	select {
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	case <-c.closed:
+		return net.ErrClosed
+	}
+}
+
+func (c *testConn) SetWriteDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *testConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+func (c *testConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func TestHubStalledWriter(t *testing.T) {
+	api := newTestApi(t)
+	api.hub.writeTimeout = 50 * time.Millisecond
+	stalled, draining := newTestConn(true), newTestConn(false)
+	api.hub.register <- newApiClient(stalled, 16, RoleViewer)
+	api.hub.register <- newApiClient(draining, 16, RoleViewer)
+	api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1"}]}`)
+
+	// the stuck client is removed once its write timed out
+	select {
+	case <-stalled.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled client not removed")
+	}
+	api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1"}]}`)
+	for start := time.Now(); draining.writes.Load() < 2; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("draining client stopped receiving")
+		}
+	}
+}
+
+// BenchmarkRelayForward forwards node messages through the relay while the hub sends them to
+// draining and stalled subscribers, the node loop must not wait for them. delivered/op is the
+// share of the messages a draining subscriber received
+func BenchmarkRelayForward(b *testing.B) {
+	msg := []byte(`{"emit":["node-ping",{"id":"n1"}]}`)
+	for _, subscribers := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("%d-subscribers", subscribers), func(b *testing.B) {
+			api := newTestApi(b)
+			conns := make([]*testConn, subscribers)
+			for i := range conns {
+				// every other subscriber is stuck on a dead connection
+				conns[i] = newTestConn(i%2 == 1)
+				api.hub.register <- newApiClient(conns[i], 64, RoleViewer)
+			}
+			b.Cleanup(func() {
+				for _, conn := range conns {
+					_ = conn.Close()
+				}
+			})
+			// the buffered channel of the app between the relay and the hub
+			ping := make(chan []byte, 256)
+			go func() {
+				for msg := range ping {
+					api.hub.channel.MsgPing <- msg
+				}
+			}()
+			relay := &NodeRelay{channel: api.hub.channel, logger: logger.NewLogger(logger.WithLevel("error"))}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				relay.forward(ping, msg)
+			}
+			b.StopTimer()
+			close(ping)
+			time.Sleep(100 * time.Millisecond)
+			b.ReportMetric(float64(conns[0].writes.Load())/float64(b.N), "delivered/op")
+		})
+	}
+}
+
+func BenchmarkHubBroadcast(b *testing.B) {
+	msg := []byte(`{"emit":["node-ping",{"id":"n1"}]}`)
+	for _, subscribers := range []int{1000, 5000, 10000} {
+		b.Run(fmt.Sprintf("%d-subscribers", subscribers), func(b *testing.B) {
+			h := newTestHub(subscribers, 64)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.writeMessage(msg)
+			}
+		})
+	}
+}
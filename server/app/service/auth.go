@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"ethstats/server/config"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// roles granted by the api tokens, an admin can do whatever a viewer can
+const (
+	RoleViewer string = "viewer"
+	RoleAdmin  string = "admin"
+)
+
+type roleKey struct{}
+
+// Protect wraps a handler of the api, it only serves the requests from an allowed origin with
+// a token granting the role. The granted role is kept in the request context
+func Protect(role string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !checkOrigin(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		granted, ok := authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or invalid api token", http.StatusUnauthorized)
+			return
+		}
+		if !authorized(granted, role) {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), roleKey{}, granted)))
+	}
+}
+
+// ProtectHandshake wraps a handler whose clients may send their token once connected, as the
+// auth payload of a socket.io client. A request with a token is protected as a viewer, one
+// without token from an allowed origin is served without any role until the client sends it
+func ProtectHandshake(handler http.HandlerFunc) http.HandlerFunc {
+	protected := Protect(RoleViewer, handler)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !config.ApiConfig.AuthEnabled() || requestToken(r) != "" {
+			protected(w, r)
+			return
+		}
+		if !checkOrigin(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), roleKey{}, "")))
+	}
+}
+
+// requestRole returns the role granted to the request, a request not passed through Protect
+// is only a viewer
+func requestRole(r *http.Request) string {
+	if role, ok := r.Context().Value(roleKey{}).(string); ok {
+		return role
+	}
+	return RoleViewer
+}
+
+// checkOrigin reports whether a browser on the origin of the request may use the api. A request
+// without origin doesn't come from a browser and is allowed, an empty list only allows the
+// same host
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(config.ApiConfig.AllowedOrigins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, allowed := range config.ApiConfig.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate returns the role granted by the token of the request
+func authenticate(r *http.Request) (string, bool) {
+	return authenticateToken(requestToken(r))
+}
+
+// authenticateToken returns the role granted by the token. Without any token configured the
+// api is open to read, every client is a viewer and the admin commands need an admin token
+func authenticateToken(token string) (string, bool) {
+	if !config.ApiConfig.AuthEnabled() {
+		return RoleViewer, true
+	}
+	if token == "" {
+		return "", false
+	}
+	if matchToken(token, config.ApiConfig.AdminTokens) {
+		return RoleAdmin, true
+	}
+	if matchToken(token, config.ApiConfig.ViewerTokens) {
+		return RoleViewer, true
+	}
+	return "", false
+}
+
+// requestToken reads the bearer token, the api key header or, as browsers can't set the
+// headers of a websocket, the token parameter
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("token")
+}
+
+func matchToken(token string, tokens []string) bool {
+	for _, t := range tokens {
+		if t != "" && subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized reports whether the granted role includes the required one
+func authorized(granted, required string) bool {
+	return granted == RoleAdmin || granted == required
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"ethstats/server/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProtect(t *testing.T) {
+	saved := *config.ApiConfig
+	defer func() { *config.ApiConfig = saved }()
+	config.ApiConfig.AllowedOrigins = []string{"https://dash.example.com"}
+	config.ApiConfig.AdminTokens = []string{"admin-token"}
+	config.ApiConfig.ViewerTokens = []string{"viewer-token"}
+
+	var role string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		role = requestRole(r)
+	}
+	tests := []struct {
+		name     string
+		required string
+		header   map[string]string
+		target   string
+		status   int
+		role     string
+	}{
+		{"no token", RoleViewer, nil, "/api", http.StatusUnauthorized, ""},
+		{"wrong token", RoleViewer, map[string]string{"Authorization": "Bearer nope"}, "/api", http.StatusUnauthorized, ""},
+		{"bearer", RoleViewer, map[string]string{"Authorization": "Bearer viewer-token"}, "/api", http.StatusOK, RoleViewer},
+		{"api key", RoleViewer, map[string]string{"X-Api-Key": "admin-token"}, "/api", http.StatusOK, RoleAdmin},
+		{"query", RoleViewer, nil, "/api?token=viewer-token", http.StatusOK, RoleViewer},
+		{"viewer on admin", RoleAdmin, map[string]string{"X-Api-Key": "viewer-token"}, "/api", http.StatusForbidden, ""},
+		{"allowed origin", RoleViewer, map[string]string{"Origin": "https://dash.example.com", "X-Api-Key": "viewer-token"}, "/api", http.StatusOK, RoleViewer},
+		{"other origin", RoleViewer, map[string]string{"Origin": "https://evil.example.com", "X-Api-Key": "admin-token"}, "/api", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		role = ""
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		Protect(tt.required, handler)(w, r)
+		if w.Code != tt.status || role != tt.role {
+			t.Errorf("%s: got status %d role %q, want %d %q", tt.name, w.Code, role, tt.status, tt.role)
+		}
+	}
+}
+
+func TestCheckOriginSameHost(t *testing.T) {
+	saved := *config.ApiConfig
+	defer func() { *config.ApiConfig = saved }()
+	config.ApiConfig.AllowedOrigins = nil
+
+	r := httptest.NewRequest(http.MethodGet, "http://stats.example.com/api", nil)
+	r.Header.Set("Origin", "http://stats.example.com")
+	if !checkOrigin(r) {
+		t.Error("same host origin should be allowed")
+	}
+	r.Header.Set("Origin", "http://other.example.com")
+	if checkOrigin(r) {
+		t.Error("cross origin should be refused without allowed origins")
+	}
+	config.ApiConfig.AllowedOrigins = []string{"*"}
+	if !checkOrigin(r) {
+		t.Error("any origin should be allowed with *")
+	}
+}
+
+func TestAuthenticateTokenOpen(t *testing.T) {
+	saved := *config.ApiConfig
+	defer func() { *config.ApiConfig = saved }()
+	config.ApiConfig.AdminTokens, config.ApiConfig.ViewerTokens = nil, nil
+
+	// without any token configured the api is open to read, never to the admin commands
+	granted, ok := authenticateToken("")
+	if !ok || granted != RoleViewer {
+		t.Fatalf("unexpected role %q, %v", granted, ok)
+	}
+	if authorized(granted, commandRoles[commandGetClients]) {
+		t.Fatal("admin command allowed without admin token")
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"encoding/json"
+	"ethstats/server/app/model"
+	"net"
+	"sort"
+	"time"
+)
+
+// commands a hub client can send, as emit [command, request]
+const (
+	commandGetNodes    string = "get-nodes"
+	commandGetNode     string = "get-node"
+	commandGetHistory  string = "get-history"
+	commandSubscribe   string = "subscribe"
+	commandUnsubscribe string = "unsubscribe"
+	commandGetClients  string = "get-clients"
+	messageResponse    string = "response"
+)
+
+// commandRoles are the commands restricted to a role, the others are allowed to any client
+var commandRoles = map[string]string{
+	commandGetClients: RoleAdmin,
+}
+
+// clientConn is the connection of a hub client, a websocket or a socket.io session
+type clientConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+// apiCommand is a command read from a hub client
+type apiCommand struct {
+	conn    clientConn
+	command string
+	request model.ApiRequest
+}
+
+// defaultQueueSize is the send queue length of a hub client if not configured
+const defaultQueueSize = 1024
+
+// apiClient is a registered hub client, its send queue and the part of the feed it subscribed to
+type apiClient struct {
+	feedFilter
+	conn    clientConn
+	role    string
+	queue   chan []byte
+	dropped int                        // messages dropped because the client was too slow
+	closed  bool                       // the queue is closed, the client was removed
+	sent    map[string]json.RawMessage // state entries last sent, to send only their changes
+	synced  time.Time                  // when the whole state was last sent
+	drops   int                        // dropped at the last sync, a message dropped since may be a patch
+}
+
+func newApiClient(conn clientConn, queueSize int, role string) *apiClient {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	return &apiClient{
+		feedFilter: newFeedFilter(),
+		conn:       conn,
+		role:       role,
+		queue:      make(chan []byte, queueSize),
+	}
+}
+
+// feedFilter is the part of the feed a client subscribed to, by node, node label and emit
+type feedFilter struct {
+	nodes  map[string]bool
+	labels map[string]bool
+	events map[string]bool
+}
+
+func newFeedFilter() feedFilter {
+	return feedFilter{
+		nodes:  make(map[string]bool),
+		labels: make(map[string]bool),
+		events: make(map[string]bool),
+	}
+}
+
+// filtered reports whether the client restricted its feed
+func (c *feedFilter) filtered() bool {
+	return len(c.nodes) > 0 || len(c.labels) > 0 || len(c.events) > 0
+}
+
+// accepts reports whether the message of the node matches the subscription, a message without
+// node id is only filtered by its emit
+func (c *feedFilter) accepts(emit, id string, labels []string) bool {
+	if len(c.events) > 0 && !c.events[emit] {
+		return false
+	}
+	if id == "" || (len(c.nodes) == 0 && len(c.labels) == 0) {
+		return true
+	}
+	if c.nodes[id] {
+		return true
+	}
+	for _, label := range labels {
+		if c.labels[label] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *feedFilter) subscribe(request model.ApiRequest) {
+	setAll(c.nodes, request.Nodes, true)
+	setAll(c.labels, request.Labels, true)
+	setAll(c.events, request.Events, true)
+}
+
+// unsubscribe removes the given items, without any item the subscription is cleared and the
+// client gets the whole feed again
+func (c *feedFilter) unsubscribe(request model.ApiRequest) {
+	if len(request.Nodes) == 0 && len(request.Labels) == 0 && len(request.Events) == 0 {
+		c.nodes = make(map[string]bool)
+		c.labels = make(map[string]bool)
+		c.events = make(map[string]bool)
+		return
+	}
+	setAll(c.nodes, request.Nodes, false)
+	setAll(c.labels, request.Labels, false)
+	setAll(c.events, request.Events, false)
+}
+
+func (c *feedFilter) subscription() model.Subscription {
+	return model.Subscription{
+		Nodes:  sortedKeys(c.nodes),
+		Labels: sortedKeys(c.labels),
+		Events: sortedKeys(c.events),
+	}
+}
+
+func setAll(set map[string]bool, keys []string, add bool) {
+	for _, key := range keys {
+		if add {
+			set[key] = true
+		} else {
+			delete(set, key)
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readLoop reads the commands of a hub client until the connection is closed
+func (h *hub) readLoop(conn clientConn) {
+	for {
+		_, content, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case h.unregister <- conn:
+			case <-h.done:
+			}
+			return
+		}
+		var msg struct {
+			Emit []json.RawMessage `json:"emit"`
+		}
+		cmd := apiCommand{conn: conn}
+		if err = json.Unmarshal(content, &msg); err == nil && len(msg.Emit) > 0 {
+			err = json.Unmarshal(msg.Emit[0], &cmd.command)
+		}
+		if err != nil || cmd.command == "" {
+			h.logger.Warnf("can't get command sent by client %s", conn.RemoteAddr())
+			continue
+		}
+		// a command without arguments may omit the request
+		if len(msg.Emit) > 1 {
+			_ = json.Unmarshal(msg.Emit[1], &cmd.request)
+		}
+		select {
+		case h.commands <- cmd:
+		case <-h.close:
+			return
+		}
+	}
+}
+
+// handleCommand answers a client command, it runs in the hub loop which owns the clients
+func (h *hub) handleCommand(cmd apiCommand) {
+	client := h.clients[cmd.conn]
+	if client == nil {
+		return
+	}
+	response := model.ApiResponse{RequestId: cmd.request.RequestId, Command: cmd.command}
+	if role, ok := commandRoles[cmd.command]; ok && !authorized(client.role, role) {
+		response.Error = "permission denied"
+		h.respond(client, response)
+		return
+	}
+	switch cmd.command {
+	case commandGetNodes:
+		response.Data = h.channel.Reports.All()
+	case commandGetNode:
+		stats := h.channel.Reports.Get(cmd.request.Id)
+		if stats == nil {
+			response.Error = "node not found"
+			break
+		}
+		response.Data = model.NodeDetail{
+			Id:      cmd.request.Id,
+			Stats:   stats,
+			Latency: h.channel.Latencies.Get(cmd.request.Id),
+			Sync:    h.channel.Syncs.Get(cmd.request.Id),
+		}
+	case commandGetHistory:
+		if !h.channel.History.Has(cmd.request.Id) {
+			response.Error = "node not found"
+			break
+		}
+		response.Data = h.channel.History.Range(cmd.request.Id, cmd.request.From, cmd.request.To)
+	case commandSubscribe:
+		client.subscribe(cmd.request)
+		response.Data = client.subscription()
+	case commandUnsubscribe:
+		client.unsubscribe(cmd.request)
+		response.Data = client.subscription()
+	case commandGetClients:
+		response.Data = h.clientInfos()
+	default:
+		response.Error = "unknown command"
+	}
+	h.respond(client, response)
+}
+
+func (h *hub) respond(client *apiClient, response model.ApiResponse) {
+	if msg := h.encodeEmit(messageResponse, response); msg != nil {
+		h.writeTo(client, msg, "", "")
+	}
+}
+
+// clientInfos describes the connected hub clients
+func (h *hub) clientInfos() []model.ApiClientInfo {
+	infos := make([]model.ApiClientInfo, 0, len(h.clients))
+	for _, client := range h.clients {
+		infos = append(infos, model.ApiClientInfo{
+			Addr:         client.conn.RemoteAddr().String(),
+			Role:         client.role,
+			Subscription: client.subscription(),
+			Queued:       len(client.queue),
+			Dropped:      client.dropped,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Addr < infos[j].Addr
+	})
+	return infos
+}
+
+// messageTarget returns the emit of the message and the node it is about, if any
+func messageTarget(msg []byte) (emit, id string) {
+	var content struct {
+		Emit []json.RawMessage `json:"emit"`
+	}
+	if err := json.Unmarshal(msg, &content); err != nil || len(content.Emit) == 0 {
+		return "", ""
+	}
+	_ = json.Unmarshal(content.Emit[0], &emit)
+	if len(content.Emit) < 2 {
+		return emit, ""
+	}
+	// ping, latency, alerts and events carry the id, the stats carry the node info
+	var target struct {
+		Id       string
+		NodeInfo struct {
+			Id string
+		}
+	}
+	_ = json.Unmarshal(content.Emit[1], &target)
+	if target.Id != "" {
+		return emit, target.Id
+	}
+	return emit, target.NodeInfo.Id
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"ethstats/server/app/model"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testApiClient is a websocket client of the /api hub
+type testApiClient struct {
+	t  *testing.T
+	ws *websocket.Conn
+}
+
+func newTestApiClient(t *testing.T, api *Api) *testApiClient {
+	server := httptest.NewServer(http.HandlerFunc(api.HandleRequest))
+	t.Cleanup(server.Close)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	return &testApiClient{t: t, ws: ws}
+}
+
+func (c *testApiClient) send(command string, request model.ApiRequest) {
+	c.t.Helper()
+	if err := c.ws.WriteJSON(map[string][]interface{}{"emit": {command, request}}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// next returns the value of the next message of the emit, skipping the others
+func (c *testApiClient) next(emit string) json.RawMessage {
+	c.t.Helper()
+	_ = c.ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		var content struct {
+			Emit []json.RawMessage `json:"emit"`
+		}
+		if name, _ := messageTarget(msg); name == emit && json.Unmarshal(msg, &content) == nil {
+			return content.Emit[1]
+		}
+	}
+}
+
+// response returns the response of the next command
+func (c *testApiClient) response() (model.ApiResponse, json.RawMessage) {
+	c.t.Helper()
+	var response struct {
+		model.ApiResponse
+		Data json.RawMessage
+	}
+	if err := json.Unmarshal(c.next(messageResponse), &response); err != nil {
+		c.t.Fatal(err)
+	}
+	return response.ApiResponse, response.Data
+}
+
+func TestHubSnapshotOnRegister(t *testing.T) {
+	api := newTestApi(t)
+	api.hub.channel.Nodes["127.0.0.1:30303"] = []byte(`{"emit":["stats",{"NodeInfo":{"Id":"n1"},"PeerCount":3}]}`)
+	client := newTestApiClient(t, api)
+
+	// the stats of the nodes and the fleet stats come at once, without waiting for a tick
+	var stats model.Stats
+	if err := json.Unmarshal(client.next(messageStats), &stats); err != nil || stats.NodeInfo.Id != "n1" || stats.PeerCount != 3 {
+		t.Fatalf("unexpected stats snapshot: %+v, %v", stats, err)
+	}
+	client.next(messageChainStats)
+}
+
+func TestHubCommands(t *testing.T) {
+	api := newTestApi(t)
+	stats := &model.Stats{Status: "ok", ChainId: "1", NodeInfo: model.Node{Id: "n1"}, Block: &model.Block{Number: 7}}
+	api.hub.channel.Reports.Set("n1", stats)
+	api.hub.channel.History.Add("n1", stats)
+	client := newTestApiClient(t, api)
+
+	client.send(commandGetNodes, model.ApiRequest{RequestId: "r1"})
+	response, data := client.response()
+	var nodes map[string]model.Stats
+	if response.RequestId != "r1" || response.Command != commandGetNodes || json.Unmarshal(data, &nodes) != nil || len(nodes) != 1 {
+		t.Fatalf("unexpected get-nodes response: %+v %s", response, data)
+	}
+
+	client.send(commandGetNode, model.ApiRequest{RequestId: "r2", Id: "n1"})
+	response, data = client.response()
+	var detail model.NodeDetail
+	if response.RequestId != "r2" || response.Error != "" || json.Unmarshal(data, &detail) != nil || detail.Stats.Block.Number != 7 {
+		t.Fatalf("unexpected get-node response: %+v %s", response, data)
+	}
+	client.send(commandGetNode, model.ApiRequest{RequestId: "r3", Id: "n2"})
+	if response, _ = client.response(); response.RequestId != "r3" || response.Error != "node not found" {
+		t.Fatalf("unexpected get-node response of a missing node: %+v", response)
+	}
+
+	client.send(commandGetHistory, model.ApiRequest{RequestId: "r4", Id: "n1"})
+	response, data = client.response()
+	var points []model.HistoryPoint
+	if response.RequestId != "r4" || json.Unmarshal(data, &points) != nil || len(points) != 1 || points[0].Block != 7 {
+		t.Fatalf("unexpected get-history response: %+v %s", response, data)
+	}
+	client.send(commandGetHistory, model.ApiRequest{RequestId: "r5", Id: "n2"})
+	if response, _ = client.response(); response.RequestId != "r5" || response.Error != "node not found" {
+		t.Fatalf("unexpected get-history response of a missing node: %+v", response)
+	}
+
+	client.send("get-everything", model.ApiRequest{RequestId: "r6"})
+	if response, _ = client.response(); response.RequestId != "r6" || response.Error != "unknown command" {
+		t.Fatalf("unexpected response of an unknown command: %+v", response)
+	}
+}
+
+func TestHubSubscribe(t *testing.T) {
+	api := newTestApi(t)
+	client := newTestApiClient(t, api)
+	ping := func(id string) {
+		api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"` + id + `"}]}`)
+	}
+	nextPing := func() string {
+		var content struct{ Id string }
+		_ = json.Unmarshal(client.next(messagePing), &content)
+		return content.Id
+	}
+
+	client.send(commandSubscribe, model.ApiRequest{RequestId: "s1", Nodes: []string{"n1"}, Events: []string{messagePing, messageResponse}})
+	response, data := client.response()
+	var subscription model.Subscription
+	if json.Unmarshal(data, &subscription) != nil || len(subscription.Nodes) != 1 || response.RequestId != "s1" {
+		t.Fatalf("unexpected subscribe response: %+v %s", response, data)
+	}
+	ping("n2")
+	ping("n1")
+	if id := nextPing(); id != "n1" {
+		t.Fatalf("ping of %s not filtered", id)
+	}
+
+	// unsubscribing without items clears the subscription
+	client.send(commandUnsubscribe, model.ApiRequest{RequestId: "s2"})
+	if response, data = client.response(); response.RequestId != "s2" || json.Unmarshal(data, &subscription) != nil || len(subscription.Nodes) != 0 {
+		t.Fatalf("unexpected unsubscribe response: %+v %s", response, data)
+	}
+	ping("n2")
+	if id := nextPing(); id != "n2" {
+		t.Fatalf("unexpected ping of %s", id)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"encoding/json"
+	"ethstats/common/util/connutil"
+	"ethstats/server/app/model"
+	"ethstats/server/config"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// actions of the classic ethstats dashboard
+const (
+	dashboardInit     string = "init"
+	dashboardAdd      string = "add"
+	dashboardUpdate   string = "update"
+	dashboardBlock    string = "block"
+	dashboardPending  string = "pending"
+	dashboardStats    string = "stats"
+	dashboardLatency  string = "latency"
+	dashboardCharts   string = "charts"
+	dashboardInactive string = "inactive"
+	dashboardReady    string = "ready" // sent by the dashboard once it listens
+)
+
+// primusPingInterval is the heartbeat of the primus client used by the dashboard
+const primusPingInterval = 25 * time.Second
+
+// dashboardNode is what the dashboard was told about a node
+type dashboardNode struct {
+	block    uint64
+	pending  uint
+	inactive bool
+}
+
+// dashboardConn translates the hub feed into the protocol of the classic ethstats dashboard,
+// a primus websocket of {action, data} messages. It is a hub client, the commands of the hub
+// are not available
+type dashboardConn struct {
+	conn    *connutil.ConnWrapper
+	channel *model.Channel
+	lock    sync.Mutex
+	nodes   map[string]*dashboardNode
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// HandleDashboard serves the classic ethstats dashboard frontend
+func (a *Api) HandleDashboard(w http.ResponseWriter, r *http.Request) {
+	upgradeConn := websocket.Upgrader{
+		CheckOrigin:       checkOrigin,
+		EnableCompression: true,
+	}
+	conn, err := connutil.NewUpgradeConn(upgradeConn, w, r)
+	if err != nil {
+		a.logger.Errorf("error trying to establish communication with dashboard (addr=%s, host=%s, URI=%s), %s",
+			r.RemoteAddr, r.Host, r.RequestURI, err)
+		return
+	}
+	a.logger.Infof("connected new dashboard! (host=%s)", r.Host)
+	d := &dashboardConn{
+		conn:    conn,
+		channel: a.hub.channel,
+		nodes:   make(map[string]*dashboardNode),
+		closed:  make(chan struct{}),
+	}
+	go d.heartbeat()
+	a.hub.register <- newApiClient(d, config.ApiConfig.QueueSize, requestRole(r))
+}
+
+// heartbeat sends the primus pings, the client reconnects if they stop
+func (d *dashboardConn) heartbeat() {
+	ticker := time.NewTicker(primusPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if d.writeJson(fmt.Sprintf("primus::ping::%d", time.Now().UnixMilli())) != nil {
+				return
+			}
+		case <-d.closed:
+			return
+		}
+	}
+}
+
+// ReadMessage answers the dashboard until the connection is closed, the dashboard sends no
+// hub command
+func (d *dashboardConn) ReadMessage() (int, []byte, error) {
+	for {
+		_, content, err := d.conn.ReadMessage()
+		if err != nil {
+			return 0, nil, err
+		}
+		var text string
+		if json.Unmarshal(content, &text) == nil {
+			if strings.HasPrefix(text, "primus::ping::") {
+				_ = d.writeJson("primus::pong::" + strings.TrimPrefix(text, "primus::ping::"))
+			}
+			continue
+		}
+		if emit, _ := messageTarget(content); emit == dashboardReady {
+			if err = d.sendInit(); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+}
+
+// WriteMessage translates a hub message into the dashboard actions, the messages without
+// counterpart are dropped
+func (d *dashboardConn) WriteMessage(_ int, data []byte) error {
+	var msg struct {
+		Emit []json.RawMessage `json:"emit"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || len(msg.Emit) < 2 {
+		return nil
+	}
+	emit, id := messageTarget(data)
+	if emit == messagePatch {
+		var patch model.StatePatch
+		_ = json.Unmarshal(msg.Emit[1], &patch)
+		emit, id = patch.Emit, patch.Id
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	switch emit {
+	case messageStats:
+		return d.updateNode(id)
+	case messageLatency:
+		var latency model.NodeLatency
+		if err := json.Unmarshal(msg.Emit[1], &latency); err != nil || d.nodes[latency.ID] == nil {
+			return nil
+		}
+		ms, _ := strconv.ParseInt(latency.Latency, 10, 64)
+		return d.write(dashboardLatency, model.DashboardLatency{Id: latency.ID, Latency: ms})
+	case eventOffline:
+		node := d.nodes[id]
+		if node == nil || node.inactive {
+			return nil
+		}
+		node.inactive = true
+		return d.write(dashboardInactive, model.DashboardNodeStats{Id: id})
+	case messageChainStats:
+		return d.write(dashboardCharts, d.charts())
+	}
+	return nil
+}
+
+// updateNode tells the dashboard the changes of the node since the last report
+func (d *dashboardConn) updateNode(id string) error {
+	stats := d.channel.Reports.Get(id)
+	if stats == nil {
+		return nil
+	}
+	latency := d.channel.Latencies.Get(id)
+	node := d.nodes[id]
+	if node == nil || node.inactive {
+		action := dashboardAdd
+		if node != nil {
+			action = dashboardUpdate
+		}
+		d.nodes[id] = newDashboardNode(stats)
+		return d.write(action, model.NewDashboardNode(id, stats, latency))
+	}
+	if stats.Block != nil && stats.Block.Number != node.block {
+		node.block = stats.Block.Number
+		if err := d.write(dashboardBlock, model.NewDashboardBlockStats(id, stats)); err != nil {
+			return err
+		}
+	}
+	if stats.Pending != node.pending {
+		node.pending = stats.Pending
+		if err := d.write(dashboardPending, model.DashboardPending{Id: id, Pending: stats.Pending}); err != nil {
+			return err
+		}
+	}
+	return d.write(dashboardStats, model.DashboardNodeStats{Id: id, Stats: model.NewDashboardStats(stats, latency)})
+}
+
+func newDashboardNode(stats *model.Stats) *dashboardNode {
+	node := &dashboardNode{pending: stats.Pending}
+	if stats.Block != nil {
+		node.block = stats.Block.Number
+	}
+	return node
+}
+
+// sendInit sends all the nodes and the charts, as the dashboard expects once ready
+func (d *dashboardConn) sendInit() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	reports := d.channel.Reports.All()
+	ids := make([]string, 0, len(reports))
+	for id := range reports {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	nodes := make([]model.DashboardNode, 0, len(ids))
+	d.nodes = make(map[string]*dashboardNode, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, model.NewDashboardNode(id, reports[id], d.channel.Latencies.Get(id)))
+		d.nodes[id] = newDashboardNode(reports[id])
+	}
+	if err := d.write(dashboardInit, nodes); err != nil {
+		return err
+	}
+	return d.write(dashboardCharts, d.charts())
+}
+
+// charts draws the chain most nodes report, the dashboard shows a single network
+func (d *dashboardConn) charts() model.DashboardCharts {
+	counts := make(map[string]int)
+	for _, stats := range d.channel.Reports.Fleet() {
+		counts[stats.ChainId]++
+	}
+	chainId, most := "", 0
+	for id, count := range counts {
+		if count > most || (count == most && id < chainId) {
+			chainId, most = id, count
+		}
+	}
+	return model.CalcDashboardCharts(d.channel.Chains.Blocks(chainId))
+}
+
+func (d *dashboardConn) write(action string, data interface{}) error {
+	return d.writeJson(map[string]interface{}{"action": action, "data": data})
+}
+
+func (d *dashboardConn) writeJson(v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return d.conn.WriteMessage(websocket.TextMessage, content)
+}
+
+func (d *dashboardConn) SetWriteDeadline(t time.Time) error {
+	return d.conn.SetWriteDeadline(t)
+}
+
+func (d *dashboardConn) RemoteAddr() net.Addr {
+	return d.conn.RemoteAddr()
+}
+
+func (d *dashboardConn) Close() error {
+	d.once.Do(func() {
+		close(d.closed)
+	})
+	return d.conn.Close()
+}
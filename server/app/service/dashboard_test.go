@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"ethstats/server/app/model"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleDashboard(t *testing.T) {
+	api := newTestApi(t)
+	api.hub.channel.Reports.Set("n1", &model.Stats{Active: true, ChainId: "1", Block: &model.Block{Number: 5}})
+	server := httptest.NewServer(http.HandlerFunc(api.HandleDashboard))
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/primus", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	// read the next message of the action
+	next := func(action string) json.RawMessage {
+		for {
+			var msg struct {
+				Action string
+				Data   json.RawMessage
+			}
+			if err := ws.ReadJSON(&msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Action == action {
+				return msg.Data
+			}
+		}
+	}
+
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`{"emit":["ready"]}`))
+	var nodes []model.DashboardNode
+	if err = json.Unmarshal(next(dashboardInit), &nodes); err != nil || len(nodes) != 1 || nodes[0].Id != "n1" {
+		t.Fatalf("unexpected init: %+v, %v", nodes, err)
+	}
+	next(dashboardCharts)
+
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`"primus::ping::123"`))
+	if _, pong, _ := ws.ReadMessage(); string(pong) != `"primus::pong::123"` {
+		t.Fatalf("unexpected pong %s", pong)
+	}
+
+	api.hub.channel.MsgEvent <- []byte(`{"emit":["node-offline",{"Id":"n1"}]}`)
+	var inactive model.DashboardNodeStats
+	if err = json.Unmarshal(next(dashboardInactive), &inactive); err != nil || inactive.Id != "n1" || inactive.Stats.Active {
+		t.Fatalf("unexpected inactive: %+v, %v", inactive, err)
+	}
+}
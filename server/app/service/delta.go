@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"ethstats/server/app/model"
+	"ethstats/server/config"
+	"reflect"
+	"time"
+)
+
+// messagePatch carries the changed fields of a state entry sent before to the hub client
+const messagePatch string = "patch"
+
+// stateEntry is a part of the state sent to the hub clients, identified by its emit and node
+type stateEntry struct {
+	emit  string
+	id    string
+	value json.RawMessage
+	msg   []byte // the whole emit message
+}
+
+func (e stateEntry) key() string {
+	return e.emit + "/" + e.id
+}
+
+// patchCache computes the patch of an entry once per previous value, as most hub clients
+// got the same value at the previous tick. It is only valid for the entries of one tick
+type patchCache map[string][]byte
+
+func (p patchCache) get(h *hub, e stateEntry, prev json.RawMessage) []byte {
+	key := e.key() + "\x00" + string(prev)
+	if msg, ok := p[key]; ok {
+		return msg
+	}
+	var msg []byte
+	patch, err := mergePatch(prev, e.value)
+	if err != nil {
+		// resend the whole entry if the patch can't be computed
+		msg = e.msg
+	} else if patch != nil {
+		msg = h.encodeEmit(messagePatch, model.StatePatch{Emit: e.emit, Id: e.id, Patch: patch})
+	}
+	p[key] = msg
+	return msg
+}
+
+// syncState sends the state to the client. The first time and every resync interval the whole
+// entries are sent, in between only the changed fields of the entries; without resync interval
+// the whole state is always sent. A client which had messages dropped since the last sync may
+// have missed a patch, so it gets the whole state too
+func (h *hub) syncState(client *apiClient, entries []stateEntry, patches patchCache, now time.Time) {
+	interval := time.Duration(config.ApiConfig.ResyncInterval) * time.Second
+	resync := interval <= 0 || client.sent == nil || now.Sub(client.synced) >= interval || client.dropped != client.drops
+	client.drops = client.dropped
+	if resync {
+		client.synced = now
+	}
+	// entries of the nodes gone are dropped with the previous map
+	sent := make(map[string]json.RawMessage, len(entries))
+	for _, e := range entries {
+		if client.filtered() && !client.accepts(e.emit, e.id, h.nodeLabels(e.id)) {
+			continue
+		}
+		sent[e.key()] = e.value
+		prev, ok := client.sent[e.key()]
+		if resync || !ok {
+			h.writeTo(client, e.msg, "", "")
+			continue
+		}
+		if msg := patches.get(h, e, prev); msg != nil {
+			h.writeTo(client, msg, "", "")
+		}
+	}
+	if interval > 0 {
+		client.sent = sent
+	}
+}
+
+// mergePatch returns the JSON merge patch (RFC 7386) turning prev into cur, nil if they are
+// equal. Arrays are replaced as a whole, and a field turned null is sent as removed
+func mergePatch(prev, cur json.RawMessage) (json.RawMessage, error) {
+	if bytes.Equal(prev, cur) {
+		return nil, nil
+	}
+	var p, c interface{}
+	if err := decodeJson(prev, &p); err != nil {
+		return nil, err
+	}
+	if err := decodeJson(cur, &c); err != nil {
+		return nil, err
+	}
+	patch, changed := diffValue(p, c)
+	if !changed {
+		return nil, nil
+	}
+	return json.Marshal(patch)
+}
+
+// decodeJson keeps the numbers as they are, so the large integers don't lose precision
+func decodeJson(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func diffValue(prev, cur interface{}) (interface{}, bool) {
+	prevObject, prevOk := prev.(map[string]interface{})
+	curObject, curOk := cur.(map[string]interface{})
+	if !prevOk || !curOk {
+		if reflect.DeepEqual(prev, cur) {
+			return nil, false
+		}
+		return cur, true
+	}
+	patch := make(map[string]interface{})
+	for k := range prevObject {
+		if _, ok := curObject[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, v := range curObject {
+		old, ok := prevObject[k]
+		if !ok {
+			patch[k] = v
+			continue
+		}
+		if d, changed := diffValue(old, v); changed {
+			patch[k] = d
+		}
+	}
+	return patch, len(patch) > 0
+}
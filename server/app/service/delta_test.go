@@ -0,0 +1,103 @@
+package service
+
+import (
+	"encoding/json"
+	"ethstats/server/app/model"
+	"ethstats/server/config"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		prev, cur, patch string
+	}{
+		{`{"a":1,"b":{"c":2}}`, `{"a":1,"b":{"c":2}}`, ``},
+		{`{"a":1,"b":{"c":2,"d":3}}`, `{"a":1,"b":{"c":4,"d":3}}`, `{"b":{"c":4}}`},
+		{`{"a":1,"b":2}`, `{"a":1}`, `{"b":null}`},
+		{`{"a":[1,2]}`, `{"a":[1,3]}`, `{"a":[1,3]}`},
+		{`{"a":{"b":1}}`, `{"a":5}`, `{"a":5}`},
+		{`{"n":123456789012345678901}`, `{"n":123456789012345678902}`, `{"n":123456789012345678902}`},
+		{`[1]`, `[2]`, `[2]`},
+	}
+	for _, tt := range tests {
+		patch, err := mergePatch(json.RawMessage(tt.prev), json.RawMessage(tt.cur))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(patch) != tt.patch {
+			t.Errorf("patch of %s to %s: got %s, want %s", tt.prev, tt.cur, patch, tt.patch)
+		}
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	saved := *config.ApiConfig
+	defer func() { *config.ApiConfig = saved }()
+	config.ApiConfig.ResyncInterval = 60
+
+	h := newTestHub(2, 16)
+	entries := func(head int) []stateEntry {
+		value := json.RawMessage(`{"head":` + strconv.Itoa(head) + `,"nodes":2}`)
+		return []stateEntry{{emit: messageChainStats, value: value, msg: h.encodeEmit(messageChainStats, value)}}
+	}
+	next := func(client *apiClient) string {
+		select {
+		case msg := <-client.queue:
+			return string(msg)
+		default:
+			return ""
+		}
+	}
+	now := time.Now()
+	for _, client := range h.clients {
+		h.syncState(client, entries(1), make(patchCache), now)
+		if msg := next(client); msg != `{"emit":["chain-stats",{"head":1,"nodes":2}]}` {
+			t.Fatalf("first sync should send the whole state, got %s", msg)
+		}
+	}
+
+	for _, client := range h.clients {
+		h.syncState(client, entries(1), make(patchCache), now.Add(15*time.Second))
+		if msg := next(client); msg != "" {
+			t.Fatalf("unchanged state should not be sent, got %s", msg)
+		}
+	}
+	patches := make(patchCache)
+	for _, client := range h.clients {
+		h.syncState(client, entries(2), patches, now.Add(30*time.Second))
+		var patch struct {
+			Emit []json.RawMessage
+		}
+		msg := next(client)
+		if err := json.Unmarshal([]byte(msg), &patch); err != nil || len(patch.Emit) != 2 {
+			t.Fatalf("can't parse patch %s", msg)
+		}
+		var p model.StatePatch
+		_ = json.Unmarshal(patch.Emit[1], &p)
+		if string(patch.Emit[0]) != `"patch"` || p.Emit != messageChainStats || string(p.Patch) != `{"head":2}` {
+			t.Fatalf("unexpected patch %s", msg)
+		}
+		h.syncState(client, entries(2), make(patchCache), now.Add(61*time.Second))
+		if msg := next(client); msg != `{"emit":["chain-stats",{"head":2,"nodes":2}]}` {
+			t.Fatalf("resync should send the whole state, got %s", msg)
+		}
+	}
+	// a client which missed a message gets the whole state again
+	for _, client := range h.clients {
+		client.dropped++
+		h.syncState(client, entries(3), make(patchCache), now.Add(75*time.Second))
+		if msg := next(client); msg != `{"emit":["chain-stats",{"head":3,"nodes":2}]}` {
+			t.Fatalf("sync after a drop should send the whole state, got %s", msg)
+		}
+		h.syncState(client, entries(4), make(patchCache), now.Add(90*time.Second))
+		if msg := next(client); !strings.HasPrefix(msg, `{"emit":["patch",`) {
+			t.Fatalf("sync without drop should send a patch, got %s", msg)
+		}
+	}
+	if len(patches) != 1 {
+		t.Fatalf("patch should be computed once for all the clients, got %d", len(patches))
+	}
+}
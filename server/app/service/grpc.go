@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"ethstats/server/app/model"
+	"ethstats/server/app/pb"
+	"ethstats/server/config"
+	"github.com/ethereum/go-ethereum/common/math"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strings"
+)
+
+// grpcServer serves the Ethstats service of ethstats.proto from the same state as the hub
+type grpcServer struct {
+	pb.UnimplementedEthstatsServer
+	api *Api
+}
+
+// NewGrpcServer creates the gRPC server of the api, it requires a viewer token as the /api
+// websocket, sent in the authorization (Bearer) or x-api-key metadata
+func (a *Api) NewGrpcServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := grpcAuthorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := grpcAuthorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	pb.RegisterEthstatsServer(server, &grpcServer{api: a})
+	return server
+}
+
+// grpcAuthorize checks the token of the call grants the viewer role
+func grpcAuthorize(ctx context.Context) error {
+	granted, ok := authenticateToken(grpcToken(ctx))
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing or invalid api token")
+	}
+	if !authorized(granted, RoleViewer) {
+		return status.Error(codes.PermissionDenied, "permission denied")
+	}
+	return nil
+}
+
+func grpcToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+			return strings.TrimSpace(auth[7:])
+		}
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+func (s *grpcServer) ListNodes(_ context.Context, request *pb.ListNodesRequest) (*pb.ListNodesResponse, error) {
+	filter := newFeedFilter()
+	setAll(filter.labels, request.Labels, true)
+	reports := s.api.hub.channel.Reports.All()
+	response := &pb.ListNodesResponse{Nodes: make([]*pb.Node, 0, len(reports))}
+	for id, stats := range reports {
+		if filter.accepts("", id, stats.NodeInfo.Labels) {
+			response.Nodes = append(response.Nodes, newPbNode(id, stats))
+		}
+	}
+	sort.Slice(response.Nodes, func(i, j int) bool {
+		return response.Nodes[i].Id < response.Nodes[j].Id
+	})
+	return response, nil
+}
+
+func (s *grpcServer) GetNode(_ context.Context, request *pb.GetNodeRequest) (*pb.NodeDetail, error) {
+	channel := s.api.hub.channel
+	stats := channel.Reports.Get(request.Id)
+	if stats == nil {
+		return nil, status.Error(codes.NotFound, "node not found")
+	}
+	content, err := json.Marshal(stats)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "can't encode the stats, %s", err)
+	}
+	detail := &pb.NodeDetail{Node: newPbNode(request.Id, stats), StatsJson: content}
+	if latency := channel.Latencies.Get(request.Id); latency != nil {
+		detail.Latency = &pb.Latency{
+			Samples:      int32(latency.Quality.Samples),
+			Min:          latency.Quality.Min,
+			Avg:          latency.Quality.Avg,
+			Max:          latency.Quality.Max,
+			Jitter:       latency.Quality.Jitter,
+			P95:          latency.Quality.P95,
+			TimeoutRatio: latency.Quality.TimeoutRatio,
+			Degraded:     latency.Degraded,
+		}
+	}
+	if sync := channel.Syncs.Get(request.Id); sync != nil {
+		detail.Sync = &pb.Sync{
+			CurrentBlock: sync.Progress.CurrentBlock,
+			HighestBlock: sync.Progress.HighestBlock,
+			Percent:      sync.Percent,
+			Rate:         sync.Rate,
+			Eta:          sync.ETA,
+		}
+	}
+	return detail, nil
+}
+
+func (s *grpcServer) GetHistory(_ context.Context, request *pb.GetHistoryRequest) (*pb.GetHistoryResponse, error) {
+	history := s.api.hub.channel.History
+	if !history.Has(request.Id) {
+		return nil, status.Error(codes.NotFound, "node not found")
+	}
+	points := history.Range(request.Id, request.From, request.To)
+	response := &pb.GetHistoryResponse{Points: make([]*pb.HistoryPoint, 0, len(points))}
+	for _, point := range points {
+		response.Points = append(response.Points, &pb.HistoryPoint{
+			Time:     point.Time,
+			Block:    point.Block,
+			Peers:    point.PeerCount,
+			Pending:  uint64(point.Pending),
+			GasPrice: decimalString(point.GasPrice),
+			Syncing:  point.Syncing,
+			Status:   point.Status,
+		})
+	}
+	return response, nil
+}
+
+// Watch streams the feed of the hub as /api/events does: the whole state first, or the buffered
+// events after last_event_id of the same epoch, then the live events matching the filters
+func (s *grpcServer) Watch(request *pb.WatchRequest, server pb.Ethstats_WatchServer) error {
+	hub := s.api.hub
+	addr := "grpc"
+	if p, ok := peer.FromContext(server.Context()); ok {
+		addr = p.Addr.String()
+	}
+	stream := newEventStream(addr, config.ApiConfig.QueueSize)
+	setAll(stream.nodes, request.Nodes, true)
+	setAll(stream.labels, request.Labels, true)
+	setAll(stream.events, request.Events, true)
+	if request.LastEventId > 0 {
+		stream.lastId, stream.lastEpoch, stream.resume = request.LastEventId, request.LastEventEpoch, true
+	}
+	select {
+	case hub.addStream <- stream:
+	case <-hub.done:
+		return status.Error(codes.Unavailable, "server closed")
+	}
+	defer func() {
+		select {
+		case hub.removeStream <- stream:
+		case <-hub.done:
+		}
+	}()
+	s.api.logger.Infof("connected new grpc watch! (addr=%s)", addr)
+
+	for {
+		select {
+		case e, ok := <-stream.queue:
+			if !ok {
+				// the hub closed or dropped the stream as too slow
+				return status.Error(codes.Unavailable, "stream closed by the server")
+			}
+			if err := server.Send(&pb.Event{Id: e.seq, Epoch: hub.events.epoch, Emit: e.emit, NodeId: e.id, Json: e.value}); err != nil {
+				return err
+			}
+		case <-server.Context().Done():
+			return nil
+		}
+	}
+}
+
+// newPbNode converts the latest report of the node
+func newPbNode(id string, stats *model.Stats) *pb.Node {
+	node := &pb.Node{
+		Id:       id,
+		Name:     stats.NodeInfo.Name,
+		Client:   stats.NodeInfo.Client,
+		ChainId:  stats.ChainId,
+		Labels:   stats.NodeInfo.Labels,
+		Status:   stats.Status,
+		Active:   stats.Active,
+		Syncing:  stats.Syncing,
+		Peers:    stats.PeerCount,
+		Pending:  uint64(stats.Pending),
+		GasPrice: decimalString(stats.GasPrice),
+	}
+	if stats.Block != nil {
+		node.Block = &pb.Block{
+			Number:   stats.Block.Number,
+			Hash:     stats.Block.Hash,
+			Time:     stats.Block.Time,
+			TxCount:  int32(stats.Block.TxCount),
+			GasUsed:  stats.Block.GasUsed,
+			GasLimit: stats.Block.GasLimit,
+		}
+	}
+	return node
+}
+
+// decimalString formats a wei amount, empty if unknown
+func decimalString(d *math.Decimal256) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
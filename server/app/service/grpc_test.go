@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"ethstats/server/app/model"
+	"ethstats/server/app/pb"
+	"ethstats/server/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestGrpcClient(t *testing.T, api *Api) pb.EthstatsClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := api.NewGrpcServer()
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewEthstatsClient(conn)
+}
+
+func TestGrpcQueries(t *testing.T) {
+	api := newTestApi(t)
+	client := newTestGrpcClient(t, api)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reports := api.hub.channel.Reports
+	reports.Set("n1", &model.Stats{Status: "ok", Active: true, PeerCount: 3, ChainId: "1",
+		NodeInfo: model.Node{Id: "n1", Labels: []string{"eu"}}, Block: &model.Block{Number: 10, TxCount: 2}})
+	reports.Set("n2", &model.Stats{Status: "ok", ChainId: "1", NodeInfo: model.Node{Id: "n2", Labels: []string{"us"}}})
+
+	list, err := client.ListNodes(ctx, &pb.ListNodesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Nodes) != 2 || list.Nodes[0].Id != "n1" || list.Nodes[1].Id != "n2" {
+		t.Fatalf("unexpected nodes: %v", list.Nodes)
+	}
+	if list, err = client.ListNodes(ctx, &pb.ListNodesRequest{Labels: []string{"eu"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Nodes) != 1 || list.Nodes[0].Block.GetNumber() != 10 || list.Nodes[0].Peers != 3 {
+		t.Fatalf("unexpected labeled nodes: %v", list.Nodes)
+	}
+
+	detail, err := client.GetNode(ctx, &pb.GetNodeRequest{Id: "n1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Node.GetId() != "n1" || detail.Latency != nil || len(detail.StatsJson) == 0 {
+		t.Fatalf("unexpected node detail: %v", detail)
+	}
+	if _, err = client.GetNode(ctx, &pb.GetNodeRequest{Id: "n3"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected error of a missing node: %v", err)
+	}
+	if _, err = client.GetHistory(ctx, &pb.GetHistoryRequest{Id: "n3"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unexpected error of a missing history: %v", err)
+	}
+}
+
+func TestGrpcWatch(t *testing.T) {
+	api := newTestApi(t)
+	client := newTestGrpcClient(t, api)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := client.Watch(ctx, &pb.WatchRequest{Nodes: []string{"n1"}, Events: []string{messageChainStats, messagePing}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the state comes first, the stream is registered once it is received
+	if e, err := watch.Recv(); err != nil || e.Emit != messageChainStats {
+		t.Fatalf("unexpected first event: %v, %v", e, err)
+	}
+	api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n2","n":1}]}`)
+	api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1","n":2}]}`)
+	e, err := watch.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Emit != messagePing || e.NodeId != "n1" || e.Id != 2 || string(e.Json) != `{"id":"n1","n":2}` {
+		t.Fatalf("unexpected event: %v", e)
+	}
+}
+
+func TestGrpcWatchResume(t *testing.T) {
+	api := newTestApi(t)
+	client := newTestGrpcClient(t, api)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := client.Watch(ctx, &pb.WatchRequest{Events: []string{messageChainStats, messagePing}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = watch.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1","n":1}]}`)
+	api.hub.channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1","n":2}]}`)
+	for i := 0; i < 2; i++ {
+		if _, err = watch.Recv(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// resumed after a buffered event, only the events missed come
+	resumed, err := client.Watch(ctx, &pb.WatchRequest{Events: []string{messageChainStats, messagePing}, LastEventId: 1, LastEventEpoch: api.hub.events.epoch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := resumed.Recv(); err != nil || e.Emit != messagePing || e.Id != 2 || e.Epoch != api.hub.events.epoch {
+		t.Fatalf("unexpected resumed event: %v, %v", e, err)
+	}
+	// an id past the latest event, or of a previous run even if lower, gets the whole state again
+	for _, request := range []*pb.WatchRequest{
+		{Events: []string{messageChainStats, messagePing}, LastEventId: 100, LastEventEpoch: api.hub.events.epoch},
+		{Events: []string{messageChainStats, messagePing}, LastEventId: 1, LastEventEpoch: "previous"},
+	} {
+		restarted, err := client.Watch(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e, err := restarted.Recv(); err != nil || e.Emit != messageChainStats || e.Id != 2 {
+			t.Fatalf("unexpected first event after %d of epoch %s: %v, %v", request.LastEventId, request.LastEventEpoch, e, err)
+		}
+	}
+}
+
+func TestGrpcAuth(t *testing.T) {
+	saved := *config.ApiConfig
+	defer func() { *config.ApiConfig = saved }()
+	config.ApiConfig.ViewerTokens = []string{"viewer-token"}
+
+	client := newTestGrpcClient(t, newTestApi(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.ListNodes(ctx, &pb.ListNodesRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error without token: %v", err)
+	}
+	watch, err := client.Watch(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer nope"), &pb.WatchRequest{})
+	if err == nil {
+		_, err = watch.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unexpected error of a wrong token: %v", err)
+	}
+	if _, err = client.ListNodes(metadata.AppendToOutgoingContext(ctx, "x-api-key", "viewer-token"), &pb.ListNodesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,13 +3,13 @@ package service
 import (
 	"encoding/json"
 	"ethstats/common/util/connutil"
-	"ethstats/common/util/emailutil"
 	"ethstats/server/app/model"
 	"ethstats/server/config"
 	"fmt"
 	"github.com/bitxx/logger/logbase"
 	"github.com/gorilla/websocket"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -20,6 +20,37 @@ const (
 	messageStats   string = "stats"
 )
 
+const (
+	messageChainStats   string = "chain-stats"
+	messageFeeStats     string = "fee-stats"
+	messageTxpool       string = "txpool-stats"
+	messagePeerStats    string = "peer-stats"
+	messageVersions     string = "client-versions"
+	messageSecurity     string = "security"
+	messageRollup       string = "rollup-stats"
+	messageFinality     string = "finality-stats"
+	messageSync         string = "sync-stats"
+	messageLatencyStats string = "latency-stats"
+	messageStale        string = "stale-nodes"
+)
+
+// node lifecycle events, sent to the hub clients as they happen
+const (
+	eventConnected     string = "node-connected"
+	eventAuthenticated string = "node-authenticated"
+	eventOffline       string = "node-offline"
+	eventStale         string = "node-stale"
+	eventRecovered     string = "node-recovered"
+)
+
+const (
+	// keepaliveInterval is how often the nodes are pinged, a node must answer within keepaliveTimeout
+	keepaliveInterval = 30 * time.Second
+	keepaliveTimeout  = 75 * time.Second
+	// staleCheckInterval is how often the nodes without recent stats are looked for
+	staleCheckInterval = 15 * time.Second
+)
+
 const (
 	ConnectError             = 1 //connect client error
 	ConnectTypeError         = 2 //client connect type error
@@ -34,19 +65,67 @@ const (
 // NodeRelay contains the secret used to authenticate the communication between
 // the Ethereum node and this server
 type NodeRelay struct {
-	secret          string
-	logger          *logbase.Helper
-	channel         *model.Channel
-	emailDelayCache map[string]*time.Time
+	secret  string
+	logger  *logbase.Helper
+	channel *model.Channel
+	alerter *Alerter
 }
 
 // NewRelay creates a new NodeRelay struct with required fields
-func NewRelay(channel *model.Channel, logger *logbase.Helper) *NodeRelay {
-	return &NodeRelay{
-		channel:         channel,
-		secret:          config.ApplicationConfig.Secret,
-		logger:          logger,
-		emailDelayCache: make(map[string]*time.Time),
+func NewRelay(channel *model.Channel, alerter *Alerter, logger *logbase.Helper) *NodeRelay {
+	relay := &NodeRelay{
+		channel: channel,
+		secret:  config.ApplicationConfig.Secret,
+		logger:  logger,
+		alerter: alerter,
+	}
+	go relay.watchStale()
+	return relay
+}
+
+// watchStale alerts on the connected nodes that sent no stats within the stale window
+func (n *NodeRelay) watchStale() {
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, node := range n.channel.Liveness.MarkStale(config.AlertConfig.StaleTime) {
+			n.broadcast(eventStale, model.NodeEvent{
+				Id:     node.Id,
+				Reason: fmt.Sprintf("no stats for %ds", node.Silent),
+			})
+			n.alerter.Raise(model.Alert{
+				Id:      node.Id,
+				Type:    "stale",
+				Level:   model.AlertWarning,
+				Message: fmt.Sprintf("connected but no stats for %ds", node.Silent),
+			})
+		}
+	}
+}
+
+// broadcast sends a node lifecycle event to the hub clients. It never blocks, an event is
+// dropped if the hub lags behind
+func (n *NodeRelay) broadcast(emit string, event model.NodeEvent) {
+	event.Time = time.Now().Unix()
+	msg, err := json.Marshal(map[string][]interface{}{"emit": {emit, event}})
+	if err != nil {
+		n.logger.Errorf("can't encode %s event, error: %s", emit, err)
+		return
+	}
+	select {
+	case n.channel.MsgEvent <- msg:
+	default:
+		n.logger.Warnf("event queue is full, %s of node[%s] not broadcast", emit, event.Id)
+	}
+}
+
+// forward passes the node message to the hub. The node loop must never wait for the hub, so
+// the message is dropped if the hub lags behind
+func (n *NodeRelay) forward(ch chan []byte, content []byte) {
+	select {
+	case ch <- content:
+	default:
+		n.logger.Warn("hub queue is full, node message not broadcast")
 	}
 }
 
@@ -70,58 +149,50 @@ func (n *NodeRelay) HandleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	n.logger.Infof("new node connected! (addr=%s, host=%s)", r.RemoteAddr, r.Host)
+	n.broadcast(eventConnected, model.NodeEvent{Addr: conn.RemoteAddr().String()})
 	go n.loop(conn)
 }
 
 // loop loops as long as the connection is alive and retrieves node packages
 func (n *NodeRelay) loop(c *connutil.ConnWrapper) {
 	errType := 0
+	// a blackholed node fails the read on the missing pong instead of staying connected
+	stopKeepalive := c.StartKeepalive(keepaliveInterval, keepaliveTimeout)
 	// Close connection if an unexpected error occurs and delete the node
 	// from the map of connected nodes...
 	defer func(c *connutil.ConnWrapper) {
+		stopKeepalive()
 		//remove the error node and login id\
 		if n.channel.Nodes[c.RemoteAddr().String()] != nil {
 			delete(n.channel.Nodes, c.RemoteAddr().String())
 		}
 
-		//send email
+		reason := errorReason(errType)
+		n.broadcast(eventOffline, model.NodeEvent{
+			Id:     n.channel.LoginIDs[c.RemoteAddr().String()],
+			Addr:   c.RemoteAddr().String(),
+			Code:   errType,
+			Reason: reason,
+		})
+
+		//send alert
 		if n.channel.LoginIDs[c.RemoteAddr().String()] != "" {
-			content := "node: [" + n.channel.LoginIDs[c.RemoteAddr().String()] + "-" + c.RemoteAddr().String() + "] "
-			switch errType {
-			case ConnectError:
-				content = content + "connect error"
-			case ConnectTypeError:
-				content = content + "connect type error"
-			case AuthParseError:
-				content = content + "auth parse error"
-			case AuthLoginSecretError:
-				content = content + "auth login secret error"
-			case AuthLoginSameNodeIDError:
-				content = content + "auth login same node id error"
-			case AuthLoginRespError:
-				content = content + "auth login response error"
-			case PingError:
-				content = content + "ping error"
-			case PingStopError:
-				content = content + "process stopped"
-			}
+			content := c.RemoteAddr().String() + " " + reason
 			if errType > 0 {
-				flag := fmt.Sprintf("%s-%d", n.channel.LoginIDs[c.RemoteAddr().String()], errType)
-				emailLatestTime := n.emailDelayCache[flag]
-				//cache time for delay send the same node email
-				now := time.Now()
-				if emailLatestTime == nil || (emailLatestTime != nil && now.Sub(*emailLatestTime).Hours() > 1) {
-					n.emailDelayCache[flag] = &now
-					err := emailutil.SendEmailDefault(fmt.Sprintf("%s-node error\n", time.Now().Format("2006-01-02 15:04:05")), content)
-					if err != nil {
-						n.logger.Error("email content: ", content, " send error: ", err)
-					} else {
-						n.logger.Info("email send success")
-					}
-				}
+				n.alerter.Raise(model.Alert{
+					Id:      n.channel.LoginIDs[c.RemoteAddr().String()],
+					Type:    fmt.Sprintf("error-%d", errType),
+					Level:   model.AlertWarning,
+					Message: content,
+				})
 			}
 
 			//remove error node
+			n.channel.Reports.Delete(n.channel.LoginIDs[c.RemoteAddr().String()])
+			n.channel.Rollups.Delete(n.channel.LoginIDs[c.RemoteAddr().String()])
+			n.channel.Syncs.Delete(n.channel.LoginIDs[c.RemoteAddr().String()])
+			n.channel.Latencies.Delete(n.channel.LoginIDs[c.RemoteAddr().String()])
+			n.channel.Liveness.Delete(n.channel.LoginIDs[c.RemoteAddr().String()])
 			delete(n.channel.LoginIDs, c.RemoteAddr().String())
 		}
 		err := c.Close()
@@ -135,6 +206,7 @@ func (n *NodeRelay) loop(c *connutil.ConnWrapper) {
 	// Client loop
 	for {
 		_, content, err := c.ReadMessage()
+		receivedAt := time.Now()
 		if err != nil {
 			errType = ConnectError
 			n.logger.Errorf("error reading message from client, %s", err)
@@ -193,6 +265,8 @@ func (n *NodeRelay) loop(c *connutil.ConnWrapper) {
 				return
 			}
 			n.channel.LoginIDs[c.RemoteAddr().String()] = authMsg.ID
+			n.channel.Liveness.Login(authMsg.ID)
+			n.broadcast(eventAuthenticated, model.NodeEvent{Id: authMsg.ID, Addr: c.RemoteAddr().String()})
 		case messagePing:
 			// When the node emit a ping message, we need to respond with pong
 			// before five seconds to authorize that node to sent reports
@@ -208,21 +282,180 @@ func (n *NodeRelay) loop(c *connutil.ConnWrapper) {
 				n.logger.Warnf("node[%s] process stopped", ping.ID)
 				return
 			}
-			sendError := ping.SendResponse(c)
+			sendError := ping.SendResponse(c, receivedAt)
 			if sendError != nil {
 				n.logger.Errorf("error sending pong response to node[%s], error: %s", ping.ID, sendError)
 			}
-			n.channel.MsgPing <- content
+			n.forward(n.channel.MsgPing, content)
 		case messageLatency:
-			n.channel.MsgLatency <- content
+			latency, err := parseLatencyMessage(msg)
+			if err != nil {
+				n.logger.Warnf("can't parse latency message sent by node[%s], error: %s", n.channel.LoginIDs[c.RemoteAddr().String()], err)
+			} else if id := n.channel.LoginIDs[c.RemoteAddr().String()]; id != "" && latency.Quality != nil {
+				n.handleLatency(id, latency.Quality)
+			}
+			n.forward(n.channel.MsgLatency, content)
 		case messageStats:
 			// use node addr as identifier to check node availability
 			n.channel.Nodes[c.RemoteAddr().String()] = content
+			stats, err := parseStatsMessage(msg)
+			if err != nil {
+				n.logger.Warnf("can't parse stats message sent by node[%s], error: %s", n.channel.LoginIDs[c.RemoteAddr().String()], err)
+			} else if id := n.channel.LoginIDs[c.RemoteAddr().String()]; id != "" {
+				n.handleStats(id, stats)
+			}
 			n.logger.Infof("currently there are %d connected nodes", len(n.channel.Nodes))
 		}
 	}
 }
 
+// errorReason describes the error code a node connection was closed with
+func errorReason(errType int) string {
+	switch errType {
+	case ConnectError:
+		return "connect error"
+	case ConnectTypeError:
+		return "connect type error"
+	case AuthParseError:
+		return "auth parse error"
+	case AuthLoginSecretError:
+		return "auth login secret error"
+	case AuthLoginSameNodeIDError:
+		return "auth login same node id error"
+	case AuthLoginRespError:
+		return "auth login response error"
+	case PingError:
+		return "ping error"
+	case PingStopError:
+		return "process stopped"
+	}
+	return ""
+}
+
+// handleStats feed the parsed stats of an authenticated node to the fleet state
+func (n *NodeRelay) handleStats(id string, stats *model.Stats) {
+	n.channel.Reports.Set(id, stats)
+	n.channel.History.Add(id, stats)
+	if n.channel.Liveness.Report(id) {
+		n.broadcast(eventRecovered, model.NodeEvent{Id: id})
+	}
+	n.channel.Syncs.Update(id, stats.Sync)
+	// rpc health is judged whatever the node state, a syncing node still serves the dApps
+	slow, failing := model.CheckRpc(stats.Rpc, config.AlertConfig.RpcLatency, config.AlertConfig.RpcErrorRate)
+	if len(slow) > 0 {
+		methods := make([]string, 0, len(slow))
+		for _, metric := range slow {
+			methods = append(methods, fmt.Sprintf("%s p95 %.0fms", metric.Method, metric.P95))
+		}
+		n.alerter.Raise(model.Alert{
+			Id:      id,
+			Type:    "rpc-slow",
+			Level:   model.AlertWarning,
+			Message: "slow rpc, " + strings.Join(methods, "; "),
+		})
+	}
+	if len(failing) > 0 {
+		methods := make([]string, 0, len(failing))
+		for _, metric := range failing {
+			methods = append(methods, fmt.Sprintf("%s %d/%d failed, %d timed out", metric.Method, metric.Errors, metric.Calls, metric.Timeouts))
+		}
+		n.alerter.Raise(model.Alert{
+			Id:      id,
+			Type:    "rpc-error",
+			Level:   model.AlertCritical,
+			Message: "failing rpc, " + strings.Join(methods, "; "),
+		})
+	}
+	if stats.Security != nil {
+		for _, finding := range stats.Security.Findings {
+			level := ""
+			switch finding.Severity {
+			case model.SeverityCritical:
+				level = model.AlertCritical
+			case model.SeverityHigh:
+				level = model.AlertWarning
+			default:
+				continue
+			}
+			n.alerter.Raise(model.Alert{
+				Id:      id,
+				Type:    "security-" + finding.Check,
+				Level:   level,
+				Message: finding.Message,
+			})
+		}
+	}
+	if stats.Clock != nil && config.AlertConfig.ClockSkew > 0 {
+		if offset := stats.Clock.Offset; offset > config.AlertConfig.ClockSkew || -offset > config.AlertConfig.ClockSkew {
+			n.alerter.Raise(model.Alert{
+				Id:      id,
+				Type:    "clock-skew",
+				Level:   model.AlertWarning,
+				Message: fmt.Sprintf("clock is off by %dms from the server, round-trip %dms", offset, stats.Clock.Rtt),
+			})
+		}
+	}
+	// a node on the wrong network still shows in the feed, but must not pollute the fleet stats
+	if stats.Status == model.StatusWrongNetwork {
+		n.alerter.Raise(model.Alert{
+			Id:      id,
+			Type:    model.StatusWrongNetwork,
+			Level:   model.AlertCritical,
+			Message: "node is on the wrong network, " + strings.Join(stats.Mismatch, "; "),
+		})
+		return
+	}
+	if stats.Rollup != nil {
+		rollup := n.channel.Rollups.Update(id, stats.Rollup, config.AlertConfig.SafeStallTime)
+		if rollup.Stalled {
+			n.alerter.Raise(model.Alert{
+				Id:      id,
+				Type:    "rollup-safe-stall",
+				Level:   model.AlertCritical,
+				Message: fmt.Sprintf("safe head stalled at %d since %s", rollup.Rollup.SafeL2, time.Unix(rollup.SafeSince, 0).Format("2006-01-02 15:04:05")),
+			})
+		}
+	}
+	if stats.Finality != nil {
+		since, stalled := n.channel.Finalities.Update(stats.ChainId, stats.Finality.Finalized, config.AlertConfig.FinalityStallTime())
+		if stalled {
+			n.alerter.Raise(model.Alert{
+				Id:      "chain-" + stats.ChainId,
+				Type:    "finality-stall",
+				Level:   model.AlertCritical,
+				Message: fmt.Sprintf("finalized head of chain %s stalled at %d since %s", stats.ChainId, stats.Finality.Finalized, time.Unix(since, 0).Format("2006-01-02 15:04:05")),
+			})
+		}
+	}
+	n.channel.Chains.Add(stats.ChainId, stats.Block)
+	n.channel.Fees.Add(stats.ChainId, stats.Fee)
+}
+
+// handleLatency records the latency quality of an authenticated node, and alerts once the
+// degradation is sustained
+func (n *NodeRelay) handleLatency(id string, quality *model.LatencyQuality) {
+	stats := n.channel.Latencies.Update(id, quality, config.AlertConfig.LatencyP95, config.AlertConfig.LatencyTimeoutRatio, config.AlertConfig.LatencySustain)
+	if stats.Degraded {
+		n.alerter.Raise(model.Alert{
+			Id:      id,
+			Type:    "latency-degraded",
+			Level:   model.AlertWarning,
+			Message: fmt.Sprintf("latency degraded for %d reports, p95 %dms, avg %.0fms, jitter %.0fms, %.0f%% pings timed out", config.AlertConfig.LatencySustain, quality.P95, quality.Avg, quality.Jitter, quality.TimeoutRatio*100),
+		})
+	}
+}
+
+// parseLatencyMessage parse the latency message sent by the Ethereum node
+func parseLatencyMessage(msg model.Message) (*model.NodeLatency, error) {
+	value, err := msg.GetValue()
+	if err != nil {
+		return &model.NodeLatency{}, err
+	}
+	var latency model.NodeLatency
+	err = json.Unmarshal(value, &latency)
+	return &latency, err
+}
+
 // parseNodePingMessage parse the current ping message sent bu the Ethereum node
 // and creates a message.NodePing struct with that info
 func parseNodePingMessage(msg model.Message) (*model.NodePing, error) {
@@ -235,6 +468,17 @@ func parseNodePingMessage(msg model.Message) (*model.NodePing, error) {
 	return &ping, err
 }
 
+// parseStatsMessage parse the stats message sent by the Ethereum node
+func parseStatsMessage(msg model.Message) (*model.Stats, error) {
+	value, err := msg.GetValue()
+	if err != nil {
+		return &model.Stats{}, err
+	}
+	var stats model.Stats
+	err = json.Unmarshal(value, &stats)
+	return &stats, err
+}
+
 // parseAuthMessage parse the current byte array and transforms it to an AuthMessage struct.
 // If an error occurs when json unmarshal, an error is returned
 func parseAuthMessage(msg model.Message) (*model.AuthMessage, error) {
@@ -0,0 +1,514 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"ethstats/common/util/connutil"
+	"ethstats/server/config"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Engine.IO v4 packet types
+const (
+	eioOpen    string = "0"
+	eioClose   string = "1"
+	eioPing    string = "2"
+	eioPong    string = "3"
+	eioMessage string = "4"
+	eioUpgrade string = "5"
+	eioNoop    string = "6"
+)
+
+// Socket.IO v4 packet types, carried by an engine.io message
+const (
+	sioConnect      byte = '0'
+	sioDisconnect   byte = '1'
+	sioEvent        byte = '2'
+	sioAck          byte = '3'
+	sioConnectError byte = '4'
+)
+
+const (
+	sioPingInterval = 25 * time.Second
+	sioPingTimeout  = 20 * time.Second
+	sioMaxPayload   = 1000000
+	sioSeparator    = "\x1e" // separates the packets of a polling payload
+)
+
+var errSessionClosed = errors.New("socket.io session closed")
+
+// sioAddr is the remote address of a session, which may span several http connections
+type sioAddr string
+
+func (a sioAddr) Network() string { return "tcp" }
+func (a sioAddr) String() string  { return string(a) }
+
+// sioSession is an Engine.IO connection carrying the default socket.io namespace, polling
+// until it is upgraded to a websocket. It is a hub client once the namespace is connected:
+// the hub emits are sent as socket.io events of the same name, and the events received are
+// read as hub commands
+type sioSession struct {
+	sid     string
+	addr    sioAddr
+	role    string // empty until the client sends a token
+	api     *Api
+	lock    sync.Mutex
+	ws      *connutil.ConnWrapper // nil until the session uses a websocket
+	pending []string              // packets waiting for the next poll
+	ready   chan struct{}         // signals a pending packet
+	in      chan []byte           // events received, as emit messages
+	acks    map[string]string     // ack id of the pending commands, by request id
+	pong    time.Time
+	joined  bool
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// HandleSocketIo serves the socket.io v4 clients, with the polling and websocket transports
+func (a *Api) HandleSocketIo(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("EIO") != "4" {
+		http.Error(w, "unsupported protocol version", http.StatusBadRequest)
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		// the origin was checked, the polling requests of a browser need the cors headers
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	sid := query.Get("sid")
+	switch query.Get("transport") {
+	case "polling":
+		if sid == "" {
+			s := a.openSession(r)
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+			_, _ = io.WriteString(w, s.openPacket(true))
+			return
+		}
+		s := a.session(sid)
+		if s == nil {
+			http.Error(w, "unknown session", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			s.poll(w, r)
+		case http.MethodPost:
+			s.receive(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "websocket":
+		var s *sioSession
+		if sid != "" {
+			if s = a.session(sid); s == nil {
+				http.Error(w, "unknown session", http.StatusBadRequest)
+				return
+			}
+		}
+		upgradeConn := websocket.Upgrader{
+			CheckOrigin:       checkOrigin,
+			EnableCompression: true,
+		}
+		conn, err := connutil.NewUpgradeConn(upgradeConn, w, r)
+		if err != nil {
+			a.logger.Errorf("error trying to establish socket.io websocket with client (addr=%s), %s", r.RemoteAddr, err)
+			return
+		}
+		if s == nil {
+			s = a.openSession(r)
+			if err = conn.WriteMessage(websocket.TextMessage, []byte(s.openPacket(false))); err != nil {
+				s.Close()
+				conn.Close()
+				return
+			}
+			s.lock.Lock()
+			s.ws = conn
+			s.lock.Unlock()
+		} else if err = s.upgrade(conn); err != nil {
+			a.logger.Warnf("can't upgrade socket.io session %s, error: %s", s.sid, err)
+			conn.Close()
+			return
+		}
+		s.readWebsocket(conn)
+	default:
+		http.Error(w, "unsupported transport", http.StatusBadRequest)
+	}
+}
+
+// openSession starts a session, its heartbeat and keeps it until it is closed
+func (a *Api) openSession(r *http.Request) *sioSession {
+	id := make([]byte, 15)
+	_, _ = rand.Read(id)
+	s := &sioSession{
+		sid:    base64.RawURLEncoding.EncodeToString(id),
+		addr:   sioAddr(r.RemoteAddr),
+		role:   requestRole(r),
+		api:    a,
+		ready:  make(chan struct{}, 1),
+		in:     make(chan []byte, 16),
+		acks:   make(map[string]string),
+		pong:   time.Now(),
+		closed: make(chan struct{}),
+	}
+	a.sessionsLock.Lock()
+	a.sessions[s.sid] = s
+	a.sessionsLock.Unlock()
+	a.logger.Infof("connected new socket.io client! (addr=%s, role=%s)", r.RemoteAddr, s.role)
+	go s.heartbeat()
+	return s
+}
+
+func (a *Api) session(sid string) *sioSession {
+	a.sessionsLock.Lock()
+	defer a.sessionsLock.Unlock()
+	return a.sessions[sid]
+}
+
+func (s *sioSession) openPacket(upgradable bool) string {
+	upgrades := []string{}
+	if upgradable {
+		upgrades = append(upgrades, "websocket")
+	}
+	handshake, _ := json.Marshal(map[string]interface{}{
+		"sid":          s.sid,
+		"upgrades":     upgrades,
+		"pingInterval": sioPingInterval.Milliseconds(),
+		"pingTimeout":  sioPingTimeout.Milliseconds(),
+		"maxPayload":   sioMaxPayload,
+	})
+	return eioOpen + string(handshake)
+}
+
+// heartbeat pings the client and closes the session once a pong is missed
+func (s *sioSession) heartbeat() {
+	ticker := time.NewTicker(sioPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.lock.Lock()
+			late := time.Since(s.pong) > sioPingInterval+sioPingTimeout
+			s.lock.Unlock()
+			if late || s.send(eioPing) != nil {
+				s.Close()
+				return
+			}
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// send writes the packet to the websocket, or keeps it for the next poll
+func (s *sioSession) send(packet string) error {
+	s.lock.Lock()
+	if ws := s.ws; ws != nil {
+		s.lock.Unlock()
+		return ws.WriteMessage(websocket.TextMessage, []byte(packet))
+	}
+	defer s.lock.Unlock()
+	select {
+	case <-s.closed:
+		return errSessionClosed
+	default:
+	}
+	// the hub queue is the buffer of a slow client, the packets only wait for the next poll
+	if len(s.pending) >= defaultQueueSize {
+		return fmt.Errorf("%d packets wait for the client to poll", len(s.pending))
+	}
+	s.pending = append(s.pending, packet)
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// poll answers the pending packets, waiting for one if there is none
+func (s *sioSession) poll(w http.ResponseWriter, r *http.Request) {
+	for {
+		s.lock.Lock()
+		packets := s.pending
+		s.pending = nil
+		s.lock.Unlock()
+		if len(packets) > 0 {
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+			_, _ = io.WriteString(w, strings.Join(packets, sioSeparator))
+			return
+		}
+		select {
+		case <-s.ready:
+		case <-s.closed:
+			http.Error(w, "session closed", http.StatusBadRequest)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// receive handles the packets posted by a polling client
+func (s *sioSession) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, sioMaxPayload))
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	for _, packet := range strings.Split(string(body), sioSeparator) {
+		s.handlePacket(packet)
+	}
+	w.Header().Set("Content-Type", "text/html")
+	_, _ = io.WriteString(w, "ok")
+}
+
+// upgrade moves a polling session to the websocket once the client probed it
+func (s *sioSession) upgrade(conn *connutil.ConnWrapper) error {
+	_, probe, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if string(probe) != eioPing+"probe" {
+		return fmt.Errorf("unexpected probe %q", probe)
+	}
+	if err = conn.WriteMessage(websocket.TextMessage, []byte(eioPong+"probe")); err != nil {
+		return err
+	}
+	// end the pending poll, so the client stops polling
+	_ = s.send(eioNoop)
+	_, upgrade, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if string(upgrade) != eioUpgrade {
+		return fmt.Errorf("unexpected upgrade %q", upgrade)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ws = conn
+	for _, packet := range s.pending {
+		if packet == eioNoop {
+			continue
+		}
+		if err = conn.WriteMessage(websocket.TextMessage, []byte(packet)); err != nil {
+			return err
+		}
+	}
+	s.pending = nil
+	return nil
+}
+
+// readWebsocket handles the packets of the websocket until it is closed
+func (s *sioSession) readWebsocket(conn *connutil.ConnWrapper) {
+	defer s.Close()
+	for {
+		_, packet, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.handlePacket(string(packet))
+	}
+}
+
+// handlePacket handles an engine.io packet
+func (s *sioSession) handlePacket(packet string) {
+	if packet == "" {
+		return
+	}
+	switch packet[:1] {
+	case eioPing:
+		_ = s.send(eioPong + packet[1:])
+	case eioPong:
+		s.lock.Lock()
+		s.pong = time.Now()
+		s.lock.Unlock()
+	case eioClose:
+		s.Close()
+	case eioMessage:
+		s.handleMessage(packet[1:])
+	}
+}
+
+// handleMessage handles a socket.io packet, only the default namespace exists
+func (s *sioSession) handleMessage(packet string) {
+	if packet == "" {
+		return
+	}
+	kind, rest, namespace := packet[0], packet[1:], "/"
+	if strings.HasPrefix(rest, "/") {
+		end := strings.Index(rest, ",")
+		if end < 0 {
+			end = len(rest)
+		}
+		namespace, rest = rest[:end], strings.TrimPrefix(rest[end:], ",")
+	}
+	if namespace != "/" {
+		_ = s.send(fmt.Sprintf(`%s%c%s,{"message":"Invalid namespace"}`, eioMessage, sioConnectError, namespace))
+		return
+	}
+	switch kind {
+	case sioConnect:
+		s.join(rest)
+	case sioDisconnect:
+		s.Close()
+	case sioEvent:
+		s.receiveEvent(rest)
+	}
+}
+
+// join connects the namespace and registers the session as a hub client. The token of the auth
+// payload, as {"token":"..."}, grants the role of the session if the handshake had none
+func (s *sioSession) join(auth string) {
+	var payload struct {
+		Token string `json:"token"`
+	}
+	if auth != "" && json.Unmarshal([]byte(auth), &payload) != nil {
+		_ = s.send(fmt.Sprintf(`%s%c{"message":"invalid auth payload"}`, eioMessage, sioConnectError))
+		return
+	}
+	s.lock.Lock()
+	if s.joined {
+		s.lock.Unlock()
+		return
+	}
+	if payload.Token != "" || s.role == "" {
+		granted, ok := authenticateToken(payload.Token)
+		if !ok {
+			s.lock.Unlock()
+			_ = s.send(fmt.Sprintf(`%s%c{"message":"missing or invalid api token"}`, eioMessage, sioConnectError))
+			return
+		}
+		s.role = granted
+	}
+	s.joined = true
+	role := s.role
+	s.lock.Unlock()
+	if err := s.send(fmt.Sprintf(`%s%c{"sid":"%s"}`, eioMessage, sioConnect, s.sid)); err != nil {
+		return
+	}
+	select {
+	case s.api.hub.register <- newApiClient(s, config.ApiConfig.QueueSize, role):
+	case <-s.api.hub.done:
+	}
+}
+
+// receiveEvent reads an event as a hub command. An event with an ack id is answered with an
+// ack instead of a response event
+func (s *sioSession) receiveEvent(packet string) {
+	s.lock.Lock()
+	joined := s.joined
+	s.lock.Unlock()
+	start := strings.IndexByte(packet, '[')
+	if !joined || start < 0 {
+		return
+	}
+	ack := packet[:start]
+	var args []json.RawMessage
+	if err := json.Unmarshal([]byte(packet[start:]), &args); err != nil || len(args) == 0 {
+		return
+	}
+	if ack != "" {
+		request := make(map[string]interface{})
+		if len(args) > 1 {
+			_ = json.Unmarshal(args[1], &request)
+		}
+		requestId, _ := request["requestId"].(string)
+		if requestId == "" {
+			requestId = "ack-" + ack
+			request["requestId"] = requestId
+		}
+		s.lock.Lock()
+		s.acks[requestId] = ack
+		s.lock.Unlock()
+		encoded, _ := json.Marshal(request)
+		args = append(args[:1], encoded)
+	}
+	msg, _ := json.Marshal(map[string][]json.RawMessage{"emit": args})
+	select {
+	case s.in <- msg:
+	case <-s.closed:
+	}
+}
+
+// ReadMessage returns the next event received, as an emit message
+func (s *sioSession) ReadMessage() (int, []byte, error) {
+	select {
+	case msg := <-s.in:
+		return websocket.TextMessage, msg, nil
+	case <-s.closed:
+		return 0, nil, errSessionClosed
+	}
+}
+
+// WriteMessage sends the emit message as a socket.io event, or as the ack of the command
+// it responds to
+func (s *sioSession) WriteMessage(_ int, data []byte) error {
+	var msg struct {
+		Emit []json.RawMessage `json:"emit"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || len(msg.Emit) == 0 {
+		return nil
+	}
+	if len(msg.Emit) > 1 && string(msg.Emit[0]) == `"`+messageResponse+`"` {
+		var response struct {
+			RequestId string
+		}
+		_ = json.Unmarshal(msg.Emit[1], &response)
+		s.lock.Lock()
+		ack, ok := s.acks[response.RequestId]
+		delete(s.acks, response.RequestId)
+		s.lock.Unlock()
+		if ok {
+			return s.send(fmt.Sprintf("%s%c%s[%s]", eioMessage, sioAck, ack, msg.Emit[1]))
+		}
+	}
+	event, err := json.Marshal(msg.Emit)
+	if err != nil {
+		return err
+	}
+	return s.send(fmt.Sprintf("%s%c%s", eioMessage, sioEvent, event))
+}
+
+// SetWriteDeadline bounds the writes of the websocket transport, the polling transport only
+// queues the packets
+func (s *sioSession) SetWriteDeadline(t time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ws == nil {
+		return nil
+	}
+	return s.ws.SetWriteDeadline(t)
+}
+
+func (s *sioSession) RemoteAddr() net.Addr {
+	return s.addr
+}
+
+// Close ends the session and its websocket
+func (s *sioSession) Close() error {
+	s.once.Do(func() {
+		close(s.closed)
+		s.lock.Lock()
+		if s.ws != nil {
+			_ = s.ws.Close()
+		}
+		s.lock.Unlock()
+		s.api.sessionsLock.Lock()
+		delete(s.api.sessions, s.sid)
+		s.api.sessionsLock.Unlock()
+	})
+	return nil
+}
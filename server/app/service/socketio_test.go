@@ -0,0 +1,174 @@
+package service
+
+import (
+	"ethstats/server/app/model"
+	"ethstats/server/config"
+	"github.com/bitxx/logger"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestApi(t testing.TB) *Api {
+	saved := *config.EmailConfig
+	t.Cleanup(func() { *config.EmailConfig = saved })
+	config.EmailConfig.MonitorTime = 86400
+
+	channel := &model.Channel{
+		MsgPing:    make(chan []byte),
+		MsgLatency: make(chan []byte),
+		MsgAlert:   make(chan []byte),
+		MsgEvent:   make(chan []byte),
+		Nodes:      make(map[string][]byte),
+		Reports:    model.NewReports(),
+		Chains:     model.NewChains(),
+		Fees:       model.NewFees(),
+		Rollups:    model.NewRollups(),
+		Finalities: model.NewFinalities(),
+		Syncs:      model.NewSyncs(),
+		Latencies:  model.NewLatencies(),
+		Liveness:   model.NewLiveness(),
+		History:    model.NewHistory(),
+	}
+	api := NewApi(channel, logger.NewLogger())
+	t.Cleanup(api.Close)
+	return api
+}
+
+func TestSocketIoPollingUpgrade(t *testing.T) {
+	api := newTestApi(t)
+	server := httptest.NewServer(http.HandlerFunc(api.HandleSocketIo))
+	defer server.Close()
+
+	request := func(method, query, body string) string {
+		req, _ := http.NewRequest(method, server.URL+"/socket.io/?EIO=4&transport=polling"+query, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		content, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: status %d, %s", method, query, resp.StatusCode, content)
+		}
+		return string(content)
+	}
+
+	open := request(http.MethodGet, "", "")
+	if !strings.HasPrefix(open, `0{`) || !strings.Contains(open, `"upgrades":["websocket"]`) {
+		t.Fatalf("unexpected open packet %s", open)
+	}
+	sid := open[strings.Index(open, `"sid":"`)+7:]
+	sid = sid[:strings.Index(sid, `"`)]
+
+	if ok := request(http.MethodPost, "&sid="+sid, "40"); ok != "ok" {
+		t.Fatalf("unexpected post answer %s", ok)
+	}
+	packets := strings.Split(request(http.MethodGet, "&sid="+sid, ""), sioSeparator)
+	if packets[0] != `40{"sid":"`+sid+`"}` {
+		t.Fatalf("unexpected connect answer %s", packets[0])
+	}
+
+	// upgrade to the websocket, the state not polled yet is sent on it
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/socket.io/?EIO=4&transport=websocket&sid="+sid, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	_ = ws.WriteMessage(websocket.TextMessage, []byte("2probe"))
+	if _, probe, _ := ws.ReadMessage(); string(probe) != "3probe" {
+		t.Fatalf("unexpected probe answer %s", probe)
+	}
+	_ = ws.WriteMessage(websocket.TextMessage, []byte("5"))
+
+	// a command with an ack id is answered with the ack
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`427["get-node",{"id":"n1"}]`))
+	for {
+		_, packet, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(string(packet), "42[") || string(packet) == "6" {
+			continue
+		}
+		if !strings.HasPrefix(string(packet), `437[{"RequestId":"ack-7","Command":"get-node","Error":"node not found"`) {
+			t.Fatalf("unexpected ack %s", packet)
+		}
+		break
+	}
+}
+
+func TestSocketIoWebsocket(t *testing.T) {
+	api := newTestApi(t)
+	server := httptest.NewServer(http.HandlerFunc(api.HandleSocketIo))
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/socket.io/?EIO=4&transport=websocket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if _, open, _ := ws.ReadMessage(); !strings.HasPrefix(string(open), `0{`) || !strings.Contains(string(open), `"upgrades":[]`) {
+		t.Fatalf("unexpected open packet %s", open)
+	}
+	_ = ws.WriteMessage(websocket.TextMessage, []byte("40/admin,"))
+	if _, refused, _ := ws.ReadMessage(); string(refused) != `44/admin,{"message":"Invalid namespace"}` {
+		t.Fatalf("unexpected namespace answer %s", refused)
+	}
+	_ = ws.WriteMessage(websocket.TextMessage, []byte("40"))
+	if _, connected, _ := ws.ReadMessage(); !strings.HasPrefix(string(connected), `40{"sid":`) {
+		t.Fatalf("unexpected connect answer %s", connected)
+	}
+	// the hub state comes as events named like the emits
+	if _, event, _ := ws.ReadMessage(); !strings.HasPrefix(string(event), `42["chain-stats",`) {
+		t.Fatalf("unexpected event %s", event)
+	}
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`42["get-nodes",{"requestId":"r1"}]`))
+	for {
+		_, packet, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(string(packet), `42["response",{"RequestId":"r1","Command":"get-nodes"`) {
+			break
+		}
+	}
+}
+
+func TestSocketIoConnectAuth(t *testing.T) {
+	saved := *config.ApiConfig
+	defer func() { *config.ApiConfig = saved }()
+	config.ApiConfig.ViewerTokens = []string{"viewer-token"}
+
+	server := httptest.NewServer(ProtectHandshake(newTestApi(t).HandleSocketIo))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/socket.io/?EIO=4&transport=websocket"
+
+	// a token in the handshake is checked at once
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"&token=nope", nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("handshake with a wrong token not refused: %v", err)
+	}
+	// without it, the namespace is only connected with the token of the auth payload
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	_, _, _ = ws.ReadMessage()
+	for _, connect := range []string{"40", `40{"token":"nope"}`} {
+		_ = ws.WriteMessage(websocket.TextMessage, []byte(connect))
+		if _, refused, _ := ws.ReadMessage(); string(refused) != `44{"message":"missing or invalid api token"}` {
+			t.Fatalf("unexpected answer of %s: %s", connect, refused)
+		}
+	}
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`40{"token":"viewer-token"}`))
+	if _, connected, _ := ws.ReadMessage(); !strings.HasPrefix(string(connected), `40{"sid":`) {
+		t.Fatalf("unexpected connect answer %s", connected)
+	}
+	if _, event, _ := ws.ReadMessage(); !strings.HasPrefix(string(event), `42["chain-stats",`) {
+		t.Fatalf("unexpected event %s", event)
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"ethstats/server/config"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseKeepalive is the interval of the comments keeping an idle event stream open through proxies
+const sseKeepalive = 15 * time.Second
+
+// defaultEventBuffer is the number of events kept to resume a stream if not configured
+const defaultEventBuffer = 1024
+
+// streamEvent is an event of the feed, numbered to resume a stream with Last-Event-ID
+type streamEvent struct {
+	seq   uint64
+	emit  string
+	id    string
+	value json.RawMessage
+}
+
+// eventBuffer keeps the latest events of the feed. The seq restarts with the process, the
+// epoch tells the events of this run from those of a previous one
+type eventBuffer struct {
+	events []streamEvent
+	size   int
+	seq    uint64
+	epoch  string
+}
+
+func newEventBuffer(size int) *eventBuffer {
+	if size <= 0 {
+		size = defaultEventBuffer
+	}
+	epoch := make([]byte, 8)
+	_, _ = rand.Read(epoch)
+	return &eventBuffer{size: size, epoch: hex.EncodeToString(epoch)}
+}
+
+// eventId is the SSE id of the event, as <epoch>-<seq>
+func (b *eventBuffer) eventId(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// add numbers the event and keeps it, dropping the oldest past the buffer size
+func (b *eventBuffer) add(emit, id string, value json.RawMessage) streamEvent {
+	b.seq++
+	e := streamEvent{seq: b.seq, emit: emit, id: id, value: value}
+	b.events = append(b.events, e)
+	// compact once the dropped events take as much room as the kept ones
+	if len(b.events) >= 2*b.size {
+		b.events = append([]streamEvent(nil), b.events[len(b.events)-b.size:]...)
+	}
+	return e
+}
+
+// kept returns the kept events, oldest first
+func (b *eventBuffer) kept() []streamEvent {
+	if len(b.events) > b.size {
+		return b.events[len(b.events)-b.size:]
+	}
+	return b.events
+}
+
+// covers tells if every event after seq of the epoch is kept. An older seq lost events dropped
+// from the buffer, another epoch numbered events of a previous run
+func (b *eventBuffer) covers(epoch string, seq uint64) bool {
+	if epoch != b.epoch || seq > b.seq {
+		return false
+	}
+	events := b.kept()
+	return len(events) == 0 || seq+1 >= events[0].seq
+}
+
+// since returns the kept events after seq, oldest first
+func (b *eventBuffer) since(seq uint64) []streamEvent {
+	events := b.kept()
+	for i, e := range events {
+		if e.seq > seq {
+			return events[i:]
+		}
+	}
+	return nil
+}
+
+// eventStream is a registered SSE client and the part of the feed it asked for
+type eventStream struct {
+	feedFilter
+	addr      string
+	lastId    uint64 // Last-Event-ID sent by the client to resume, 0 for a new stream
+	lastEpoch string // epoch of lastId
+	resume    bool
+	queue     chan streamEvent
+	dropped   int
+	closed    bool
+}
+
+// newEventStream creates the stream of the client at addr, which accepts the whole feed until
+// its filters are set
+func newEventStream(addr string, queueSize int) *eventStream {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	return &eventStream{
+		feedFilter: newFeedFilter(),
+		addr:       addr,
+		queue:      make(chan streamEvent, queueSize),
+	}
+}
+
+// readRequest reads the filters and the resume position of the request. The filters are
+// comma separated node ids, node labels and emits, as the subscribe command
+func (s *eventStream) readRequest(r *http.Request) {
+	query := r.URL.Query()
+	setAll(s.nodes, splitParam(query["nodes"]), true)
+	setAll(s.labels, splitParam(query["labels"]), true)
+	setAll(s.events, splitParam(query["events"]), true)
+	lastId := r.Header.Get("Last-Event-ID")
+	if lastId == "" {
+		// a browser EventSource can't set the header on its first request
+		lastId = query.Get("lastEventId")
+	}
+	// the id is <epoch>-<seq>, an id without epoch resumes nothing and gets the whole state
+	if i := strings.LastIndexByte(lastId, '-'); i >= 0 {
+		s.lastEpoch, lastId = lastId[:i], lastId[i+1:]
+	}
+	if seq, err := strconv.ParseUint(lastId, 10, 64); err == nil {
+		s.lastId, s.resume = seq, true
+	}
+}
+
+func splitParam(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// HandleEvents streams the feed of the hub as Server-Sent Events, the event name is the emit
+// and the data its value. A new stream first gets the whole state, a stream resumed with
+// Last-Event-ID gets the buffered events it missed
+func (a *Api) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	stream := newEventStream(r.RemoteAddr, config.ApiConfig.QueueSize)
+	stream.readRequest(r)
+	select {
+	case a.hub.addStream <- stream:
+	case <-a.hub.done:
+		http.Error(w, "server closed", http.StatusServiceUnavailable)
+		return
+	}
+	defer func() {
+		select {
+		case a.hub.removeStream <- stream:
+		case <-a.hub.done:
+		}
+	}()
+	a.logger.Infof("connected new event stream! (addr=%s)", r.RemoteAddr)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case e, ok := <-stream.queue:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", a.hub.events.eventId(e.seq), e.emit, e.value); err != nil {
+				return
+			}
+			// send the events already queued at once
+			for n := len(stream.queue); n > 0; n-- {
+				if e, ok = <-stream.queue; !ok {
+					break
+				}
+				_, _ = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", a.hub.events.eventId(e.seq), e.emit, e.value)
+			}
+			flusher.Flush()
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// startStream registers the stream and queues the events it starts with. A stream which can't
+// be resumed from the buffer gets the whole state as a new one
+func (h *hub) startStream(stream *eventStream) {
+	h.streams[stream] = true
+	if stream.resume && h.events.covers(stream.lastEpoch, stream.lastId) {
+		for _, e := range h.events.since(stream.lastId) {
+			h.sendEvent(stream, e)
+		}
+		return
+	}
+	// the state is numbered as the latest event, so the stream resumes after it
+	for _, entry := range h.state() {
+		h.sendEvent(stream, streamEvent{seq: h.events.seq, emit: entry.emit, id: entry.id, value: entry.value})
+	}
+}
+
+// publish numbers the event, keeps it to resume the streams and sends it to the streams
+func (h *hub) publish(emit, id string, value json.RawMessage) {
+	if emit == "" || value == nil {
+		return
+	}
+	e := h.events.add(emit, id, value)
+	for stream := range h.streams {
+		h.sendEvent(stream, e)
+	}
+}
+
+// publishMessage publishes an emit message broadcast to the hub clients
+func (h *hub) publishMessage(msg []byte) {
+	var content struct {
+		Emit []json.RawMessage `json:"emit"`
+	}
+	if err := json.Unmarshal(msg, &content); err != nil || len(content.Emit) < 2 {
+		return
+	}
+	emit, id := messageTarget(msg)
+	h.publish(emit, id, content.Emit[1])
+}
+
+// sendEvent queues the event to the stream if its filters accept it, a slow stream is treated
+// as a slow hub client
+func (h *hub) sendEvent(stream *eventStream, e streamEvent) {
+	if stream.closed || (stream.filtered() && !stream.accepts(e.emit, e.id, h.nodeLabels(e.id))) {
+		return
+	}
+	select {
+	case stream.queue <- e:
+		return
+	default:
+	}
+	if config.ApiConfig.SlowPolicy == config.SlowPolicyDisconnect {
+		h.logger.Warnf("event stream %s is too slow, disconnect", stream.addr)
+		h.stopStream(stream)
+		return
+	}
+	select {
+	case <-stream.queue:
+		stream.dropped++
+	default:
+	}
+	stream.queue <- e
+}
+
+// stopStream removes the stream, its handler returns once the queue is closed
+func (h *hub) stopStream(stream *eventStream) {
+	if stream.closed {
+		return
+	}
+	h.logger.Infof("Closed event stream: %s", stream.addr)
+	if stream.dropped > 0 {
+		h.logger.Warnf("%d events to stream %s were dropped", stream.dropped, stream.addr)
+	}
+	stream.closed = true
+	close(stream.queue)
+	delete(h.streams, stream)
+}
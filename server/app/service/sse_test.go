@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bufio"
+	"ethstats/server/app/model"
+	"ethstats/server/config"
+	"fmt"
+	"github.com/bitxx/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventBuffer(t *testing.T) {
+	b := newEventBuffer(3)
+	for i := 0; i < 10; i++ {
+		b.add(messagePing, "n1", []byte(fmt.Sprintf(`{"i":%d}`, i)))
+	}
+	events := b.since(0)
+	if len(events) != 3 || events[0].seq != 8 || events[2].seq != 10 {
+		t.Fatalf("unexpected kept events: %+v", events)
+	}
+	if events = b.since(9); len(events) != 1 || events[0].seq != 10 {
+		t.Fatalf("unexpected events since 9: %+v", events)
+	}
+	if events = b.since(10); len(events) != 0 {
+		t.Fatalf("unexpected events since the latest: %+v", events)
+	}
+	for seq, covered := range map[uint64]bool{6: false, 7: true, 10: true, 11: false} {
+		if b.covers(b.epoch, seq) != covered {
+			t.Errorf("events since %d covered: %v", seq, !covered)
+		}
+	}
+	if b.covers("", 9) || b.covers(newEventBuffer(3).epoch, 9) {
+		t.Error("events of another epoch covered")
+	}
+}
+
+func TestStartStreamOutOfBuffer(t *testing.T) {
+	h := newTestHub(0, 0)
+	h.events = newEventBuffer(3)
+	h.channel = &model.Channel{
+		Nodes:      map[string][]byte{"127.0.0.1:30303": []byte(`{"emit":["stats",{"NodeInfo":{"Id":"n1"}}]}`)},
+		Reports:    model.NewReports(),
+		Chains:     model.NewChains(),
+		Fees:       model.NewFees(),
+		Rollups:    model.NewRollups(),
+		Finalities: model.NewFinalities(),
+		Syncs:      model.NewSyncs(),
+		Latencies:  model.NewLatencies(),
+		Liveness:   model.NewLiveness(),
+	}
+	for i := 0; i < 10; i++ {
+		h.publish(messagePing, "n1", []byte(fmt.Sprintf(`{"i":%d}`, i)))
+	}
+	// the first kept event is 8, a stream resumed after 7 misses nothing
+	start := func(epoch string, lastId uint64) []streamEvent {
+		stream := newEventStream("test", 16)
+		stream.lastId, stream.lastEpoch, stream.resume = lastId, epoch, true
+		h.startStream(stream)
+		var events []streamEvent
+		for len(stream.queue) > 0 {
+			events = append(events, <-stream.queue)
+		}
+		return events
+	}
+	if events := start(h.events.epoch, 7); len(events) != 3 || events[0].seq != 8 {
+		t.Fatalf("unexpected resumed events: %+v", events)
+	}
+	// events after 5 were dropped from the buffer, the seq 20 is past the latest one, and the
+	// seq 8 of a previous run is lower than the counter but numbered other events: all the
+	// streams get the whole state numbered as the latest event
+	previous := newEventBuffer(3).epoch
+	for _, lastId := range []struct {
+		epoch string
+		seq   uint64
+	}{{h.events.epoch, 5}, {h.events.epoch, 20}, {previous, 8}, {"", 8}} {
+		events := start(lastId.epoch, lastId.seq)
+		if len(events) == 0 || events[0].emit != messageStats || events[0].id != "n1" {
+			t.Fatalf("stream resumed after %+v didn't get the state: %+v", lastId, events)
+		}
+		for _, e := range events {
+			if e.seq != 10 || e.emit == messagePing {
+				t.Fatalf("unexpected event of the state: %+v", e)
+			}
+		}
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	saved := *config.EmailConfig
+	defer func() { *config.EmailConfig = saved }()
+	config.EmailConfig.MonitorTime = 86400
+
+	channel := &model.Channel{
+		MsgPing:    make(chan []byte),
+		MsgLatency: make(chan []byte),
+		MsgAlert:   make(chan []byte),
+		MsgEvent:   make(chan []byte),
+		Nodes:      make(map[string][]byte),
+		Reports:    model.NewReports(),
+		Chains:     model.NewChains(),
+		Fees:       model.NewFees(),
+		Rollups:    model.NewRollups(),
+		Finalities: model.NewFinalities(),
+		Syncs:      model.NewSyncs(),
+		Latencies:  model.NewLatencies(),
+		Liveness:   model.NewLiveness(),
+		History:    model.NewHistory(),
+	}
+	api := NewApi(channel, logger.NewLogger())
+	defer api.Close()
+	server := httptest.NewServer(http.HandlerFunc(api.HandleEvents))
+	defer server.Close()
+
+	// open a stream, it is registered once the retry line is received
+	open := func(header http.Header, query string) (*bufio.Scanner, func()) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+query, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("unexpected content type %s", ct)
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "retry: ") {
+			t.Fatalf("unexpected stream start: %s", scanner.Text())
+		}
+		return scanner, func() { _ = resp.Body.Close() }
+	}
+	// read the id and data of the next n events
+	next := func(scanner *bufio.Scanner, n int) []string {
+		var events []string
+		var id string
+		for len(events) < n && scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: "+api.hub.events.epoch+"-")
+			case strings.HasPrefix(line, "event: ") && line != "event: "+messagePing:
+				t.Fatalf("event not filtered: %s", line)
+			case strings.HasPrefix(line, "data: "):
+				events = append(events, id+" "+strings.TrimPrefix(line, "data: "))
+			}
+		}
+		return events
+	}
+
+	stream, closeStream := open(nil, "?events=node-ping&nodes=n1")
+	defer closeStream()
+	channel.MsgAlert <- []byte(`{"emit":["alert",{"Id":"n1"}]}`)
+	channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1","n":1}]}`)
+	channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n2","n":1}]}`)
+	channel.MsgPing <- []byte(`{"emit":["node-ping",{"id":"n1","n":2}]}`)
+	if events := next(stream, 2); len(events) != 2 || events[0] != `2 {"id":"n1","n":1}` || events[1] != `4 {"id":"n1","n":2}` {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	// resume after the first ping of n1
+	resumed, closeResumed := open(http.Header{"Last-Event-Id": {api.hub.events.eventId(2)}}, "?events=node-ping&nodes=n1")
+	defer closeResumed()
+	if events := next(resumed, 1); len(events) != 1 || events[0] != `4 {"id":"n1","n":2}` {
+		t.Fatalf("unexpected resumed events: %v", events)
+	}
+}
@@ -33,6 +33,26 @@ const (
 	emailTo            = "email-to"
 	emailSubjectPrefix = "email-subject-prefix"
 	monitorTime        = "email-monitor-time"
+	safeStallTime      = "alert-safe-stall-time"
+	finalityStall      = "alert-finality-stall-epochs"
+	epochTime          = "alert-epoch-time"
+	rpcLatency         = "alert-rpc-latency"
+	rpcErrorRate       = "alert-rpc-error-rate"
+	clockSkew          = "alert-clock-skew"
+	latencyP95         = "alert-latency-p95"
+	latencyTimeout     = "alert-latency-timeout-ratio"
+	latencySustain     = "alert-latency-sustain"
+	staleTime          = "alert-stale-time"
+	apiQueueSize       = "api-queue-size"
+	apiSlowPolicy      = "api-slow-policy"
+	apiResyncInterval  = "api-resync-interval"
+	apiEventBuffer     = "api-event-buffer"
+	apiSocketIo        = "api-socket-io"
+	apiDashboard       = "api-dashboard"
+	apiGrpcPort        = "api-grpc-port"
+	apiAllowedOrigins  = "api-allowed-origins"
+	apiAdminTokens     = "api-admin-tokens"
+	apiViewerTokens    = "api-viewer-tokens"
 )
 
 func init() {
@@ -104,6 +124,68 @@ func init() {
 			if monitorTime, _ := flag.GetInt(monitorTime); monitorTime > 0 && config.EmailConfig.MonitorTime <= 0 {
 				config.EmailConfig.MonitorTime = monitorTime
 			}
+			// the alert settings missing from the config file keep their default, an alert flag
+			// given on the command line overrides the file and 0 disables the alert
+			if flag.Changed(safeStallTime) {
+				config.AlertConfig.SafeStallTime, _ = flag.GetInt64(safeStallTime)
+			}
+			if flag.Changed(finalityStall) {
+				config.AlertConfig.FinalityStallEpochs, _ = flag.GetInt64(finalityStall)
+			}
+			if flag.Changed(epochTime) {
+				config.AlertConfig.EpochTime, _ = flag.GetInt64(epochTime)
+			}
+			if flag.Changed(rpcLatency) {
+				config.AlertConfig.RpcLatency, _ = flag.GetInt64(rpcLatency)
+			}
+			if flag.Changed(rpcErrorRate) {
+				config.AlertConfig.RpcErrorRate, _ = flag.GetFloat64(rpcErrorRate)
+			}
+			if flag.Changed(clockSkew) {
+				config.AlertConfig.ClockSkew, _ = flag.GetInt64(clockSkew)
+			}
+			if flag.Changed(latencyP95) {
+				config.AlertConfig.LatencyP95, _ = flag.GetInt64(latencyP95)
+			}
+			if flag.Changed(latencyTimeout) {
+				config.AlertConfig.LatencyTimeoutRatio, _ = flag.GetFloat64(latencyTimeout)
+			}
+			if flag.Changed(latencySustain) {
+				config.AlertConfig.LatencySustain, _ = flag.GetInt(latencySustain)
+			}
+			if flag.Changed(staleTime) {
+				config.AlertConfig.StaleTime, _ = flag.GetInt64(staleTime)
+			}
+			if apiQueueSize, _ := flag.GetInt(apiQueueSize); apiQueueSize > 0 && config.ApiConfig.QueueSize <= 0 {
+				config.ApiConfig.QueueSize = apiQueueSize
+			}
+			if apiSlowPolicy, _ := flag.GetString(apiSlowPolicy); apiSlowPolicy != "" && config.ApiConfig.SlowPolicy == "" {
+				config.ApiConfig.SlowPolicy = apiSlowPolicy
+			}
+			if apiResyncInterval, _ := flag.GetInt64(apiResyncInterval); apiResyncInterval > 0 && config.ApiConfig.ResyncInterval <= 0 {
+				config.ApiConfig.ResyncInterval = apiResyncInterval
+			}
+			if apiEventBuffer, _ := flag.GetInt(apiEventBuffer); apiEventBuffer > 0 && config.ApiConfig.EventBuffer <= 0 {
+				config.ApiConfig.EventBuffer = apiEventBuffer
+			}
+			if apiSocketIo, _ := flag.GetBool(apiSocketIo); apiSocketIo {
+				config.ApiConfig.SocketIo = true
+			}
+			if apiDashboard, _ := flag.GetBool(apiDashboard); apiDashboard {
+				config.ApiConfig.Dashboard = true
+			}
+			if apiGrpcPort, _ := flag.GetString(apiGrpcPort); apiGrpcPort != "" && config.ApiConfig.GrpcPort == "" {
+				config.ApiConfig.GrpcPort = apiGrpcPort
+			}
+			if apiAllowedOrigins, _ := flag.GetStringSlice(apiAllowedOrigins); len(apiAllowedOrigins) > 0 && len(config.ApiConfig.AllowedOrigins) == 0 {
+				config.ApiConfig.AllowedOrigins = apiAllowedOrigins
+			}
+			if apiAdminTokens, _ := flag.GetStringSlice(apiAdminTokens); len(apiAdminTokens) > 0 && len(config.ApiConfig.AdminTokens) == 0 {
+				config.ApiConfig.AdminTokens = apiAdminTokens
+			}
+			if apiViewerTokens, _ := flag.GetStringSlice(apiViewerTokens); len(apiViewerTokens) > 0 && len(config.ApiConfig.ViewerTokens) == 0 {
+				config.ApiConfig.ViewerTokens = apiViewerTokens
+			}
 
 			if config.ApplicationConfig.Name == "" {
 				log.Fatal("param name can't empty")
@@ -145,6 +227,26 @@ func init() {
 	cmd.String(emailTo, "", "email to")
 	cmd.String(emailSubjectPrefix, "", "email subject prefix")
 	cmd.Int(monitorTime, 86400, "email monitor time")
+	cmd.Int64(safeStallTime, config.AlertConfig.SafeStallTime, "alert when the L2 safe head stalls for the seconds, 0 disables the alert")
+	cmd.Int64(finalityStall, config.AlertConfig.FinalityStallEpochs, "alert when the finalized head stalls for the epochs, 0 disables the alert")
+	cmd.Int64(epochTime, config.AlertConfig.EpochTime, "epoch time in second, 0 disables the finality stall alert")
+	cmd.Int64(rpcLatency, config.AlertConfig.RpcLatency, "alert when the p95 latency of a node rpc method exceeds the milliseconds, 0 disables the alert")
+	cmd.Float64(rpcErrorRate, config.AlertConfig.RpcErrorRate, "alert when the error rate of a node rpc method exceeds the rate, 0 disables the alert")
+	cmd.Int64(clockSkew, config.AlertConfig.ClockSkew, "alert when the clock of a node drifts from the server beyond the milliseconds, 0 disables the alert")
+	cmd.Int64(latencyP95, config.AlertConfig.LatencyP95, "latency p95 in milliseconds above which a node report is degraded, 0 disables the check")
+	cmd.Float64(latencyTimeout, config.AlertConfig.LatencyTimeoutRatio, "ping timeout ratio above which a node report is degraded, 0 disables the check")
+	cmd.Int(latencySustain, config.AlertConfig.LatencySustain, "alert when the latency of a node is degraded for the consecutive reports, 0 disables the alert")
+	cmd.Int64(staleTime, config.AlertConfig.StaleTime, "alert when a connected node sent no stats for the seconds, 0 disables the check")
+	cmd.Int(apiQueueSize, 1024, "send queue length of every api client")
+	cmd.String(apiSlowPolicy, config.SlowPolicyDropOldest, "when the queue of an api client is full: drop-oldest, disconnect")
+	cmd.Int64(apiResyncInterval, 0, "seconds between the whole state sent to the api clients, only the changes are sent in between, 0 always sends the whole state")
+	cmd.Int(apiEventBuffer, 1024, "number of the latest events kept to resume the event streams")
+	cmd.Bool(apiSocketIo, false, "serve the socket.io v4 clients on /socket.io/")
+	cmd.Bool(apiDashboard, false, "serve the classic ethstats dashboard frontend on /primus")
+	cmd.String(apiGrpcPort, "", "port of the gRPC api, disabled if empty")
+	cmd.StringSlice(apiAllowedOrigins, nil, "browser origins allowed to use the api, comma separated, * for any")
+	cmd.StringSlice(apiAdminTokens, nil, "api tokens of the admin role, comma separated")
+	cmd.StringSlice(apiViewerTokens, nil, "api tokens of the read-only viewer role, comma separated")
 }
 
 func run() error {
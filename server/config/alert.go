@@ -0,0 +1,33 @@
+package config
+
+type Alert struct {
+	SafeStallTime       int64
+	FinalityStallEpochs int64
+	EpochTime           int64
+	RpcLatency          int64   //毫秒，rpc方法p95耗时超过该值时告警
+	RpcErrorRate        float64 //rpc方法错误率超过该值时告警，0~1
+	ClockSkew           int64   //毫秒，节点时钟与服务端偏差超过该值时告警
+	LatencyP95          int64   //毫秒，节点延迟p95超过该值视为劣化
+	LatencyTimeoutRatio float64 //节点ping超时占比超过该值视为劣化，0~1
+	LatencySustain      int     //连续劣化的上报次数达到该值时告警
+	StaleTime           int64   //秒，节点已连接但超过该时间未上报stats时视为stale
+}
+
+// FinalityStallTime returns the seconds the finalized height may not advance
+func (e *Alert) FinalityStallTime() int64 {
+	return e.FinalityStallEpochs * e.EpochTime
+}
+
+// AlertConfig starts with the defaults of the settings missing from the config file, an explicit
+// 0 disables the alert
+var AlertConfig = &Alert{
+	SafeStallTime:       600,
+	FinalityStallEpochs: 3,
+	EpochTime:           384,
+	RpcLatency:          2000,
+	RpcErrorRate:        0.2,
+	ClockSkew:           500,
+	LatencyP95:          1000,
+	LatencyTimeoutRatio: 0.1,
+	LatencySustain:      6,
+}
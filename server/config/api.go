@@ -0,0 +1,26 @@
+package config
+
+const (
+	SlowPolicyDropOldest = "drop-oldest"
+	SlowPolicyDisconnect = "disconnect"
+)
+
+type Api struct {
+	QueueSize      int      //每个前端连接的发送队列长度
+	SlowPolicy     string   //队列已满时的处理：drop-oldest丢弃最旧的消息，disconnect断开该连接
+	ResyncInterval int64    //全量数据的发送间隔，单位秒，其间只发送变化的字段；0表示每次都发送全量数据
+	EventBuffer    int      //缓存的最近事件数，SSE断线重连时通过Last-Event-ID补发
+	SocketIo       bool     //是否在/socket.io/提供socket.io v4协议的接口
+	Dashboard      bool     //是否在/primus提供兼容经典ethstats-dashboard前端的接口
+	GrpcPort       string   //gRPC接口的端口，为空时不启用
+	AllowedOrigins []string //允许访问的浏览器来源，为空时只允许同源，*表示不限制
+	AdminTokens    []string //admin角色的token，可执行全部命令
+	ViewerTokens   []string //viewer角色的token，只读；admin和viewer的token都为空时不鉴权，所有连接均为viewer
+}
+
+var ApiConfig = new(Api)
+
+// AuthEnabled reports whether a token is required to use the api
+func (e *Api) AuthEnabled() bool {
+	return len(e.AdminTokens) > 0 || len(e.ViewerTokens) > 0
+}
@@ -11,6 +11,8 @@ type Config struct {
 	Application *Application `yaml:"application"`
 	Logger      *Logger      `yaml:"logger"`
 	Email       *Email       `yaml:"email"`
+	Alert       *Alert       `yaml:"alert"`
+	Api         *Api         `yaml:"api"`
 	callbacks   []func()
 }
 
@@ -41,6 +43,8 @@ func Setup(s source.Source,
 		Application: ApplicationConfig,
 		Logger:      LoggerConfig,
 		Email:       EmailConfig,
+		Alert:       AlertConfig,
+		Api:         ApiConfig,
 		callbacks:   fs,
 	}
 	var err error